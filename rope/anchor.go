@@ -0,0 +1,21 @@
+package rope
+
+func (r *ropeImpl[Id, T]) AnchorOf(id Id) (Anchor[Id, T], bool) {
+	node := r.byId[id]
+	if node == nil {
+		return Anchor[Id, T]{}, false
+	}
+	return Anchor[Id, T]{node: node, gen: node.gen}, true
+}
+
+func (r *ropeImpl[Id, T]) SpliceAt(a Anchor[Id, T], deleteUntilId *Id, insertId *Id, data T) (removed []Removed[Id, T], err error) {
+	if a.node == nil || a.node.gen != a.gen || r.byId[a.node.id] != a.node {
+		return nil, ErrBadAnchor
+	}
+	return r.spliceFrom(a.node, deleteUntilId, insertId, data, nil)
+}
+
+func (r *ropeImpl[Id, T]) InsertAt(a Anchor[Id, T], newId Id, data T) error {
+	_, err := r.SpliceAt(a, nil, &newId, data)
+	return err
+}
@@ -0,0 +1,64 @@
+package rope
+
+import (
+	"testing"
+)
+
+func TestAnchor(t *testing.T) {
+	r := buildIdRope(3)
+
+	a, ok := r.AnchorOf(2)
+	if !ok {
+		t.Fatalf("couldn't get anchor")
+	}
+	if err := r.InsertAt(a, 10, "abc"); err != nil {
+		t.Fatalf("couldn't insert at anchor: %v", err)
+	}
+	if r.Info(10).Prev != 2 || r.Find(10) != 5 {
+		t.Errorf("bad insert at anchor: %+v", r.Info(10))
+	}
+
+	r.Delete(1, 2)
+	if err := r.InsertAt(a, 11, "abc"); err != ErrBadAnchor {
+		t.Errorf("expected ErrBadAnchor for removed anchor, got: %v", err)
+	}
+
+	// the removed node may be reused by a new entry, which should not revive the anchor
+	r.Insert(1, 12, "x")
+	if err := r.InsertAt(a, 11, "abc"); err != ErrBadAnchor {
+		t.Errorf("expected ErrBadAnchor after reuse, got: %v", err)
+	}
+
+	if _, ok := r.AnchorOf(2); ok {
+		t.Errorf("expected no anchor for removed id")
+	}
+	if err := r.InsertAt(Anchor[int, SizedString]{}, 11, "abc"); err != ErrBadAnchor {
+		t.Errorf("expected ErrBadAnchor for zero anchor, got: %v", err)
+	}
+
+	// an anchor from another rope isn't valid here, even if the same Id is
+	other := buildIdRope(3)
+	count := r.Count()
+	foreign, _ := other.AnchorOf(3)
+	if err := r.InsertAt(foreign, 11, "abc"); err != ErrBadAnchor {
+		t.Errorf("expected ErrBadAnchor for anchor from another rope, got: %v", err)
+	}
+	if r.Count() != count || other.Count() != 3 {
+		t.Errorf("expected neither rope to change")
+	}
+}
+
+func BenchmarkInsertById(b *testing.B) {
+	r := buildIdRope(100_000)
+	for b.Loop() {
+		r.Insert(50_000, nextId(), "x")
+	}
+}
+
+func BenchmarkInsertByAnchor(b *testing.B) {
+	r := buildIdRope(100_000)
+	a, _ := r.AnchorOf(50_000)
+	for b.Loop() {
+		r.InsertAt(a, nextId(), "x")
+	}
+}
@@ -0,0 +1,76 @@
+package rope
+
+import (
+	"slices"
+)
+
+// Annotation is a range in a Rope which moves and stretches as content is inserted or removed.
+// Its start and end each move as a Marker would, so content inserted at its start is inside it, but content inserted at its end is not.
+type Annotation struct {
+	start, end int
+
+	// Payload is any value the caller wants to keep with this Annotation.
+	Payload any
+	// OnRemove, if set, is called when all content in this Annotation is removed, which also removes it from its Rope.
+	OnRemove func(a *Annotation)
+}
+
+// Range returns the current [start,end) range of this Annotation.
+func (a *Annotation) Range() (start, end int) {
+	return a.start, a.end
+}
+
+func (r *ropeImpl[Id, T]) AddAnnotation(startPos, endPos int, payload any) *Annotation {
+	start := max(0, min(startPos, r.len))
+	end := max(start, min(endPos, r.len))
+	a := &Annotation{start: start, end: end, Payload: payload}
+	r.annotations = append(r.annotations, a)
+	if r.txDepth != 0 {
+		r.record(func() { r.RemoveAnnotation(a) })
+	}
+	return a
+}
+
+func (r *ropeImpl[Id, T]) RemoveAnnotation(a *Annotation) bool {
+	i := slices.Index(r.annotations, a)
+	if i == -1 {
+		return false
+	}
+	r.annotations = slices.Delete(r.annotations, i, i+1)
+	if r.txDepth != 0 {
+		r.record(func() { r.annotations = slices.Insert(r.annotations, min(i, len(r.annotations)), a) })
+	}
+	return true
+}
+
+// shiftAnnotations moves annotations as for shiftMarkers, removing those whose content is all removed.
+func (r *ropeImpl[Id, T]) shiftAnnotations(pos, delta int) {
+	r.moveAnnotations(func(at int) int {
+		return shiftPos(at, pos, delta)
+	})
+}
+
+// moveAnnotations moves the start and end of every annotation by move, removing those whose content is all removed.
+func (r *ropeImpl[Id, T]) moveAnnotations(move func(pos int) int) {
+	var removed []*Annotation
+	keep := r.annotations[:0]
+	for _, a := range r.annotations {
+		wasEmpty := a.start == a.end
+		a.start = move(a.start)
+		a.end = max(a.start, move(a.end))
+		if !wasEmpty && a.start == a.end {
+			removed = append(removed, a)
+			continue
+		}
+		keep = append(keep, a)
+	}
+	clear(r.annotations[len(keep):])
+	r.annotations = keep
+
+	// call after updating, in case OnRemove looks at the Rope
+	for _, a := range removed {
+		if a.OnRemove != nil {
+			a.OnRemove(a)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package rope
+
+import (
+	"testing"
+)
+
+func TestAnnotation(t *testing.T) {
+	r := New[int, SizedString]()
+	r.Insert(0, 1, "hello")
+	r.Insert(1, 2, " there")
+
+	a := r.AddAnnotation(2, 8, "diag")
+	var removed []*Annotation
+	a.OnRemove = func(a *Annotation) { removed = append(removed, a) }
+
+	checkRange := func(wantStart, wantEnd int) {
+		t.Helper()
+		if start, end := a.Range(); start != wantStart || end != wantEnd {
+			t.Errorf("expected [%d,%d), got [%d,%d)", wantStart, wantEnd, start, end)
+		}
+	}
+
+	// inserting inside grows it
+	r.Insert(1, 3, "!!")
+	checkRange(2, 10)
+
+	// inserting before moves it, and at its end doesn't change it
+	r.Insert(0, 4, "abc")
+	checkRange(5, 13)
+	r.AddMarker(0) // markers and annotations are tracked together
+	r.Insert(3, 5, "xy")
+	checkRange(5, 15)
+
+	// deleting part of it shrinks it
+	r.Delete(3, 5)
+	checkRange(5, 13)
+	if len(removed) != 0 {
+		t.Fatalf("expected nothing removed yet")
+	}
+
+	// deleting all its content removes it
+	r.Delete(0, 2)
+	if len(removed) != 1 || removed[0] != a || removed[0].Payload != "diag" {
+		t.Errorf("expected annotation to be removed, got: %v", removed)
+	}
+	if r.RemoveAnnotation(a) {
+		t.Errorf("expected annotation to already be removed")
+	}
+
+	if b := r.AddAnnotation(-5, 1000, nil); !r.RemoveAnnotation(b) {
+		t.Errorf("expected annotation to be removed once")
+	} else if start, end := b.Range(); start != 0 || end != r.Len() {
+		t.Errorf("expected annotation clamped to [0,%d), got [%d,%d)", r.Len(), start, end)
+	}
+}
+
+func TestAnnotationReplaceKeepingId(t *testing.T) {
+	r := New[int, SizedString]()
+	r.Insert(0, 1, "hello")
+	r.Insert(1, 2, " there")
+	r.Insert(2, 3, "!")
+
+	keep := func(old int) int { return old }
+	a := r.AddAnnotation(7, 12, nil)
+	b := r.AddAnnotation(0, 9, nil)
+	if err := r.ReplaceKeepingId(2, "ab", 2, keep); err != nil {
+		t.Fatalf("couldn't replace: %v", err)
+	}
+	if start, end := a.Range(); start != 5 || end != 8 {
+		t.Errorf("expected start clamped to the shrunk entry, got [%d,%d)", start, end)
+	}
+	if start, end := b.Range(); start != 0 || end != 5 {
+		t.Errorf("expected end remapped within the shrunk entry, got [%d,%d)", start, end)
+	}
+
+	// an annotation whose content is all replaced away is removed
+	c := r.AddAnnotation(6, 7, nil)
+	var removed bool
+	c.OnRemove = func(*Annotation) { removed = true }
+	if err := r.ReplaceKeepingId(2, "", 0, keep); err != nil {
+		t.Fatalf("couldn't replace: %v", err)
+	}
+	if !removed || r.RemoveAnnotation(c) {
+		t.Errorf("expected annotation to be removed")
+	}
+}
+
+func TestAnnotationTransaction(t *testing.T) {
+	r := buildIdRope(10)
+	a := r.AddAnnotation(2, 4, nil)
+
+	r.Transaction(func(tx Rope[int, SizedString]) error {
+		tx.Delete(0, 8)
+		return errAbort
+	})
+	if start, end := a.Range(); start != 2 || end != 4 {
+		t.Errorf("expected annotation restored after rollback, got [%d,%d)", start, end)
+	}
+	r.Delete(2, 3)
+	if start, end := a.Range(); start != 2 || end != 3 {
+		t.Errorf("expected restored annotation to still be updated, got [%d,%d)", start, end)
+	}
+
+	// adding and removing annotations is also undone
+	var added *Annotation
+	r.Transaction(func(tx Rope[int, SizedString]) error {
+		added = tx.AddAnnotation(5, 7, nil)
+		tx.RemoveAnnotation(a)
+		return errAbort
+	})
+	if r.RemoveAnnotation(added) {
+		t.Errorf("expected added annotation to be gone")
+	}
+	if !r.RemoveAnnotation(a) {
+		t.Errorf("expected removed annotation to be restored")
+	}
+}
@@ -0,0 +1,241 @@
+package rope
+
+import (
+	"math/bits"
+	"math/rand/v2"
+	"sync"
+)
+
+// BuildFromSlice builds a new Rope[Id, T] containing the given entries in order.
+// Only the Id, Len and Data of each entry are used.
+// This costs O(n), rather than the ~O(nlogn) of inserting each entry in turn.
+func BuildFromSlice[Id comparable, T any](entries []Info[Id, T]) (Rope[Id, T], error) {
+	r := newRope(*new(Id), *new(T), maxHeight)
+	if err := r.appendNodes(entries); err != nil {
+		return nil, err
+	}
+	if err := r.indexAfter(&r.head, len(entries)); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// BuildParallel is as BuildFromSlice, but builds up to workers parts of the Rope concurrently before joining them.
+// The result has the same entries in the same order as BuildFromSlice.
+// Indexing each Id is still done on the calling goroutine.
+func BuildParallel[Id comparable, T any](entries []Info[Id, T], workers int) (Rope[Id, T], error) {
+	workers = min(workers, len(entries))
+	if workers <= 1 {
+		return BuildFromSlice(entries)
+	}
+
+	parts := make([]*ropeImpl[Id, T], workers)
+	errs := make([]error, workers)
+
+	var wg sync.WaitGroup
+	for i := range workers {
+		start := len(entries) * i / workers
+		end := len(entries) * (i + 1) / workers
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			parts[i] = &ropeImpl[Id, T]{}
+			parts[i].head.levels = make([]ropeLevel[Id, T], 1, maxHeight)
+			parts[i].head.levels[0] = ropeLevel[Id, T]{prev: &parts[i].head}
+			parts[i].height = 1
+			parts[i].heightLimit = maxHeight
+			errs[i] = parts[i].appendNodes(entries[start:end])
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r := newRope(*new(Id), *new(T), maxHeight)
+	for _, part := range parts {
+		r.link(part)
+	}
+	if err := r.indexAfter(&r.head, len(entries)); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// buildLike builds a new rope containing entries, with the same head and settings as this one.
+func (r *ropeImpl[Id, T]) buildLike(entries []Info[Id, T]) (*ropeImpl[Id, T], error) {
+	out := newRope(r.head.id, r.head.dl.Data, r.heightLimit)
+	out.lenFn = r.lenFn
+	out.strictLengths = r.strictLengths
+	out.measureFns = r.measureFns
+	if r.seed != nil {
+		// continue from the same state, but independently
+		seed := *r.seed
+		out.seed = &seed
+		out.rng = rand.New(out.seed)
+	} else if r.rng != nil {
+		// a *rand.Rand isn't safe to share, so draw an independent generator from it
+		out.rng = rand.New(rand.NewPCG(r.rng.Uint64(), r.rng.Uint64()))
+	}
+	out.heightBits, out.heightBitsLeft = r.heightBits, r.heightBitsLeft
+	if r.measured() {
+		out.nodeMeasures = map[*ropeNode[Id, T]][]measures{}
+	}
+	if err := out.appendNodes(entries); err != nil {
+		return nil, err
+	}
+	if err := out.indexAfter(&out.head, len(entries)); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// appendNodes links new nodes for all entries onto the end of this rope in O(n).
+// It does not update byId or tail: call indexAfter once done.
+func (r *ropeImpl[Id, T]) appendNodes(entries []Info[Id, T]) error {
+	// tails holds the last node at every level, which is the node that new nodes are linked after
+	var tailsStack [maxHeight]*ropeNode[Id, T]
+	tails, pooled := r.getNodes(&tailsStack, max(r.height, r.heightLimit))
+	defer r.putNodes(pooled)
+	r.rseekNodes(r.tailNode(), tails)
+	total := r.totalCount()
+
+	for _, e := range entries {
+		if e.Len < 0 {
+			return ErrNegativeLength
+		}
+		node := &ropeNode[Id, T]{
+			id:     e.Id,
+			dl:     e.DataLen,
+			levels: make([]ropeLevel[Id, T], r.randomHeight()),
+		}
+		r.appendNode(tails, node, total)
+		total++
+	}
+
+	return nil
+}
+
+// appendNode links a node, whose levels are sized to its height and empty, onto the end of this rope.
+// The tails are the last node at every level, as for appendNodes, and total is the count before this node.
+func (r *ropeImpl[Id, T]) appendNode(tails []*ropeNode[Id, T], node *ropeNode[Id, T], total int) {
+	var m measures
+	var nm []measures
+	if r.measured() {
+		m = r.measureOf(node.dl.Data)
+		nm = r.levelMeasures(node)
+	}
+
+	height := len(node.levels)
+	for i := range height {
+		if i == r.height {
+			r.head.levels = append(r.head.levels, ropeLevel[Id, T]{
+				prev:        &r.head,
+				subtreesize: r.len,
+				count:       total,
+			})
+			r.height++
+			if nm != nil {
+				r.levelMeasures(&r.head)[i] = r.measure
+			}
+			tails[i] = &r.head
+		}
+		tails[i].levels[i].next = node
+		node.levels[i] = ropeLevel[Id, T]{
+			prev:        tails[i],
+			subtreesize: node.dl.Len,
+			count:       1,
+		}
+		if nm != nil {
+			nm[i] = m
+		}
+		tails[i] = node
+	}
+	for i := height; i < r.height; i++ {
+		tails[i].levels[i].subtreesize += node.dl.Len
+		tails[i].levels[i].count++
+		if nm != nil {
+			tm := r.levelMeasures(tails[i])
+			tm[i] = tm[i].add(m)
+		}
+	}
+
+	r.len += node.dl.Len
+	r.contentCount += hasLen(node.dl.Len)
+	r.measure = r.measure.add(m)
+}
+
+func (r *ropeImpl[Id, T]) Grow(expectedCount int) {
+	// heights halve in frequency at each level, so this many entries reach about this many levels
+	height := min(bits.Len(uint(max(expectedCount, 0))), r.heightLimit)
+	total := r.Count()
+	for h := r.height; h < height; h++ {
+		// the new top level has no other nodes yet, so it covers everything
+		r.head.levels = append(r.head.levels, ropeLevel[Id, T]{
+			prev:        &r.head,
+			subtreesize: r.len,
+			count:       total,
+		})
+		r.height++
+		if r.measured() {
+			r.levelMeasures(&r.head)[h] = r.measure
+		}
+	}
+}
+
+func (r *ropeImpl[Id, T]) Rebalance() {
+	node := r.head.levels[0].next
+	clear(r.head.levels[:cap(r.head.levels)])
+	r.head.levels = r.head.levels[:1]
+	r.head.levels[0] = ropeLevel[Id, T]{prev: &r.head}
+	r.height = 1
+	r.len = 0
+	r.contentCount = 0
+	r.measure = measures{}
+	delete(r.nodeMeasures, &r.head)
+	r.version++
+
+	var tailsStack [maxHeight]*ropeNode[Id, T]
+	tails, pooled := r.getNodes(&tailsStack, r.heightLimit)
+	defer r.putNodes(pooled)
+	tails[0] = &r.head
+
+	// relink the same nodes in order, so byId, tail, and any Anchor or parked iterator stay valid
+	var total int
+	for node != nil {
+		next := node.levels[0].next
+		height := r.randomHeight()
+		if cap(node.levels) < height {
+			node.levels = make([]ropeLevel[Id, T], height)
+		} else {
+			node.levels = node.levels[:height]
+			clear(node.levels)
+		}
+		r.appendNode(tails, node, total)
+		total++
+		node = next
+	}
+}
+
+// indexAfter adds every node after the given node to byId, and updates tail.
+// The count is a hint for the number of nodes.
+func (r *ropeImpl[Id, T]) indexAfter(node *ropeNode[Id, T], count int) error {
+	if len(r.byId) == 1 {
+		// rebuild now rather than growing the map repeatedly
+		r.byId = make(map[Id]*ropeNode[Id, T], count+1)
+		r.byId[r.head.id] = &r.head
+	}
+
+	for node = node.levels[0].next; node != nil; node = node.levels[0].next {
+		if _, exists := r.byId[node.id]; exists {
+			return ErrIdExists
+		}
+		r.byId[node.id] = node
+		r.tail = node
+	}
+	return nil
+}
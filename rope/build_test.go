@@ -0,0 +1,248 @@
+package rope
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func randomEntries(count int) []Info[int, SizedString] {
+	entries := make([]Info[int, SizedString], count)
+	for i := range entries {
+		length := rand.IntN(4)
+		entries[i] = Info[int, SizedString]{
+			Id:      nextId(),
+			DataLen: DataLen[SizedString]{Len: length, Data: SizedString("abcd"[:length])},
+		}
+	}
+	return entries
+}
+
+func collect[Id comparable, T any](r Rope[Id, T]) (out []Info[Id, T]) {
+	for id, dl := range r.Iter(*new(Id)) {
+		out = append(out, Info[Id, T]{Id: id, DataLen: dl})
+	}
+	return out
+}
+
+func checkEntries(t *testing.T, r Rope[int, SizedString], entries []Info[int, SizedString]) {
+	t.Helper()
+
+	if err := r.Validate(); err != nil {
+		t.Fatalf("invalid rope: %v", err)
+	}
+	if r.Count() != len(entries) {
+		t.Fatalf("bad count: wanted=%d, got=%d", len(entries), r.Count())
+	}
+
+	var pos int
+	for i, e := range entries {
+		pos += e.Len
+		if at := r.Find(e.Id); at != pos {
+			t.Fatalf("bad find for index=%d: wanted=%d, got=%d", i, pos, at)
+		}
+		if e.Len == 0 {
+			continue
+		}
+		if id, offset := r.ByPosition(pos, false); id != e.Id || offset != 0 {
+			t.Fatalf("bad byPosition for index=%d: wanted=%d, got=%d/%d", i, e.Id, id, offset)
+		}
+	}
+	if r.Len() != pos {
+		t.Errorf("bad len: wanted=%d, got=%d", pos, r.Len())
+	}
+
+	var lastId int
+	if len(entries) != 0 {
+		lastId = entries[len(entries)-1].Id
+	}
+	if r.LastId() != lastId {
+		t.Errorf("bad lastId: wanted=%d, got=%d", lastId, r.LastId())
+	}
+}
+
+func TestBuildFromSlice(t *testing.T) {
+	entries := randomEntries(1000)
+
+	r, err := BuildFromSlice(entries)
+	if err != nil {
+		t.Fatalf("couldn't build: %v", err)
+	}
+	checkEntries(t, r, entries)
+
+	// can still modify as normal
+	newId := nextId()
+	r.Insert(entries[500].Id, newId, "xyz")
+	if r.Find(newId) != r.Find(entries[500].Id)+3 {
+		t.Errorf("bad insert after build")
+	}
+
+	_, err = BuildFromSlice(append(entries, entries[0]))
+	if err != ErrIdExists {
+		t.Errorf("expected ErrIdExists for duplicate, got: %v", err)
+	}
+}
+
+func TestBuildParallel(t *testing.T) {
+	entries := randomEntries(10_000)
+
+	for _, workers := range []int{0, 1, 3, 8, 20_000} {
+		r, err := BuildParallel(entries, workers)
+		if err != nil {
+			t.Fatalf("couldn't build with workers=%d: %v", workers, err)
+		}
+		checkEntries(t, r, entries)
+
+		seq, _ := BuildFromSlice(entries)
+		if !reflect.DeepEqual(collect(r), collect(seq)) {
+			t.Errorf("parallel build with workers=%d didn't match sequential build", workers)
+		}
+	}
+
+	_, err := BuildParallel(append(entries, entries[0]), 4)
+	if err != ErrIdExists {
+		t.Errorf("expected ErrIdExists for duplicate across parts, got: %v", err)
+	}
+}
+
+func TestConcat(t *testing.T) {
+	left := randomEntries(200)
+	right := randomEntries(5)
+
+	r, _ := BuildFromSlice(left)
+	other, _ := BuildFromSlice(right)
+
+	if err := r.Concat(other); err != nil {
+		t.Fatalf("couldn't concat: %v", err)
+	}
+	checkEntries(t, r, append(left, right...))
+	checkEntries(t, other, nil)
+
+	// empty ropes concat fine on either side
+	empty := New[int, SizedString]()
+	if err := empty.Concat(r); err != nil {
+		t.Fatalf("couldn't concat onto empty: %v", err)
+	}
+	checkEntries(t, empty, append(left, right...))
+
+	dup, _ := BuildFromSlice(right[:1])
+	if err := empty.Concat(dup); err != ErrIdExists {
+		t.Errorf("expected ErrIdExists, got: %v", err)
+	}
+
+	wrapped := wrappedRope[int, SizedString]{dup}
+	if err := empty.Concat(wrapped); err != ErrForeignRope {
+		t.Errorf("expected ErrForeignRope, got: %v", err)
+	}
+	if dup.Count() != 1 {
+		t.Errorf("expected nothing to move")
+	}
+}
+
+// wrappedRope is a Rope from outside this package, which forwards to another.
+type wrappedRope[Id comparable, T any] struct {
+	Rope[Id, T]
+}
+
+func BenchmarkBuildFromSlice(b *testing.B) {
+	entries := randomEntries(1_000_000)
+	for b.Loop() {
+		BuildFromSlice(entries)
+	}
+}
+
+func BenchmarkBuildParallel(b *testing.B) {
+	entries := randomEntries(1_000_000)
+	for b.Loop() {
+		BuildParallel(entries, 8)
+	}
+}
+
+func TestNewInfo(t *testing.T) {
+	if _, err := NewDataLen(SizedString("abc"), -1); err != ErrNegativeLength {
+		t.Errorf("expected ErrNegativeLength, got: %v", err)
+	}
+	if _, err := NewInfo(1, SizedString("abc"), -1); err != ErrNegativeLength {
+		t.Errorf("expected ErrNegativeLength, got: %v", err)
+	}
+
+	a, err := NewInfo(1, SizedString("abc"), 3)
+	if err != nil {
+		t.Fatalf("couldn't make info: %v", err)
+	}
+	b, _ := NewInfo(2, SizedString(""), 0)
+	r, err := BuildFromSlice([]Info[int, SizedString]{a, b})
+	if err != nil {
+		t.Fatalf("couldn't build: %v", err)
+	}
+	checkEntries(t, r, []Info[int, SizedString]{a, b})
+}
+
+func TestGrow(t *testing.T) {
+	organic := New[int, SizedString]()
+	grown := New[int, SizedString]()
+	grown.Grow(1 << 20)
+	grownImpl := grown.(*ropeImpl[int, SizedString])
+	if grownImpl.height != 21 {
+		t.Errorf("expected height=21, got=%d", grownImpl.height)
+	}
+	if err := grown.Validate(); err != nil {
+		t.Fatalf("invalid when empty: %v", err)
+	}
+
+	ids := []int{0}
+	for range 2_000 {
+		if len(ids) > 2 && rand.IntN(4) == 0 {
+			choice := 1 + rand.IntN(len(ids)-1)
+			prev := organic.Info(ids[choice]).Prev
+			organic.Delete(prev, ids[choice])
+			grown.Delete(prev, ids[choice])
+			ids = append(ids[:choice], ids[choice+1:]...)
+			continue
+		}
+		after, newId := ids[rand.IntN(len(ids))], nextId()
+		s := SizedString("abcd"[:rand.IntN(5)])
+		organic.Insert(after, newId, s)
+		grown.Insert(after, newId, s)
+		ids = append(ids, newId)
+	}
+	if err := grown.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+
+	for _, id := range ids {
+		if organic.Find(id) != grown.Find(id) || organic.RankLogN(id) != grown.RankLogN(id) {
+			t.Fatalf("different position for id=%d", id)
+		}
+	}
+	for pos := range organic.Len() + 1 {
+		oid, ooff := organic.ByPosition(pos, false)
+		gid, goff := grown.ByPosition(pos, false)
+		if oid != gid || ooff != goff {
+			t.Fatalf("different ByPosition(%d): %d/%d vs %d/%d", pos, oid, ooff, gid, goff)
+		}
+	}
+
+	// growing is capped, and never shrinks
+	small := NewSmall[int, SizedString]("")
+	small.Grow(1 << 20)
+	if h := small.(*ropeImpl[int, SizedString]).height; h != smallMaxHeight {
+		t.Errorf("expected height capped at %d, got=%d", smallMaxHeight, h)
+	}
+	grown.Grow(1)
+	if grownImpl.height != 21 {
+		t.Errorf("expected height to stay, got=%d", grownImpl.height)
+	}
+
+	// new levels of a non-empty rope cover everything, including custom measures
+	weighted := NewWeighted[int](func(s SizedString) int { return 2 * len(s) })
+	weighted.Insert(0, 1, "abc")
+	weighted.Grow(1000)
+	weighted.Insert(1, 2, "de")
+	if err := weighted.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+	if id, offset := weighted.ByWeight(7); id != 2 || offset != 3 {
+		t.Errorf("bad ByWeight after grow: %d/%d", id, offset)
+	}
+}
@@ -0,0 +1,23 @@
+package rope
+
+func (r *ropeImpl[Id, T]) Clone() Rope[Id, T] {
+	return r.CloneWith(nil)
+}
+
+func (r *ropeImpl[Id, T]) CloneWith(copyData func(T) T) Rope[Id, T] {
+	entries := make([]Info[Id, T], 0, r.Count())
+	for node := r.head.levels[0].next; node != nil; node = node.levels[0].next {
+		dl := node.dl
+		if copyData != nil {
+			dl.Data = copyData(dl.Data)
+		}
+		entries = append(entries, Info[Id, T]{Id: node.id, DataLen: dl})
+	}
+
+	// every Id is already unique and every length is valid, so this can't fail
+	out, _ := r.buildLike(entries)
+	if copyData != nil {
+		out.head.dl.Data = copyData(out.head.dl.Data)
+	}
+	return out
+}
@@ -0,0 +1,89 @@
+package rope
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	r := buildIdRope(10)
+	c := r.Clone()
+	if err := c.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+	if materialize(c) != materialize(r) || c.LastId() != 10 || c.Find(5) != 5 {
+		t.Errorf("expected same entries, got: %q", materialize(c))
+	}
+
+	c.Delete(0, 5)
+	if r.Count() != 10 || c.Count() != 5 {
+		t.Errorf("expected independent ropes: count=%d clone count=%d", r.Count(), c.Count())
+	}
+}
+
+func TestCloneWith(t *testing.T) {
+	r := NewWithLenFunc[int](func(b []byte) int { return len(b) })
+	r.Insert(0, 1, []byte("hello"))
+	r.Insert(1, 2, []byte("there"))
+
+	shallow := r.Clone()
+	deep := r.CloneWith(bytes.Clone)
+
+	r.Info(1).Data[0] = 'j'
+	if got := string(r.Info(1).Data); got != "jello" {
+		t.Errorf("expected change, got: %q", got)
+	}
+	if got := string(shallow.Info(1).Data); got != "jello" {
+		t.Errorf("expected shallow clone to share data, got: %q", got)
+	}
+	if got := string(deep.Info(1).Data); got != "hello" {
+		t.Errorf("expected deep clone to be unaffected, got: %q", got)
+	}
+	if deep.Len() != 10 {
+		t.Errorf("expected deep clone to keep lengths, got: %d", deep.Len())
+	}
+}
+
+func TestCloneSettings(t *testing.T) {
+	build := func() Rope[int, SizedString] {
+		r := New[int, SizedString]()
+		r.Reseed(1)
+		r.StrictLengths(true)
+		for i := range 100 {
+			r.Insert(i, i+1, "ab")
+		}
+		return r
+	}
+
+	// changing the original after cloning doesn't change the clone's heights
+	a, b := build(), build()
+	ca, cb := a.Clone(), b.Clone()
+	for i := 100; i < 200; i++ {
+		a.Insert(i, i+1, "ab")
+	}
+	for _, c := range []Rope[int, SizedString]{ca, cb} {
+		for i := 100; i < 1000; i++ {
+			c.Insert(i, i+1, "ab")
+		}
+	}
+	if !slices.Equal(ca.HeightHistogram(), cb.HeightHistogram()) {
+		t.Errorf("expected seeded clones to match: %v vs %v", ca.HeightHistogram(), cb.HeightHistogram())
+	}
+
+	if _, err := ca.InsertInfo(0, 5000, "abc", 2); err != ErrLengthMismatch {
+		t.Errorf("expected clone to keep StrictLengths, got: %v", err)
+	}
+}
+
+func TestCloneOwnGenerator(t *testing.T) {
+	r := buildIdRope(10)
+	impl := r.(*ropeImpl[int, SizedString])
+	impl.rng = rand.New(rand.NewPCG(1, 2))
+
+	c := r.Clone().(*ropeImpl[int, SizedString])
+	if c.rng == nil || c.rng == impl.rng {
+		t.Errorf("expected clone to have its own generator")
+	}
+}
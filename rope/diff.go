@@ -0,0 +1,50 @@
+package rope
+
+import (
+	"reflect"
+)
+
+func (r *ropeImpl[Id, T]) CommonPrefixLen(other Rope[Id, T]) (count int) {
+	a := r.head.levels[0].next
+	for id, dl := range other.Iter(other.HeadId()) {
+		if a == nil || !sameEntry(a, id, dl) {
+			break
+		}
+		count++
+		a = a.levels[0].next
+	}
+	return count
+}
+
+func (r *ropeImpl[Id, T]) CommonSuffixLen(other Rope[Id, T]) (count int) {
+	a := r.tail
+	for id, dl := range other.IterReverse() {
+		if a == &r.head || !sameEntry(a, id, dl) {
+			break
+		}
+		count++
+		a = a.levels[0].prev
+	}
+	return count
+}
+
+func (r *ropeImpl[Id, T]) SameOrder(other Rope[Id, T]) bool {
+	if r.Count() != other.Count() {
+		return false
+	}
+
+	a := r.head.levels[0].next
+	for id := range other.Iter(other.HeadId()) {
+		if a == nil || a.id != id {
+			return false
+		}
+		a = a.levels[0].next
+	}
+	return a == nil
+}
+
+// sameEntry compares the Id and data of a node to an entry, which may be from another Rope.
+// Data may not be comparable, so this uses reflect.DeepEqual.
+func sameEntry[Id comparable, T any](node *ropeNode[Id, T], id Id, dl DataLen[T]) bool {
+	return node.id == id && node.dl.Len == dl.Len && reflect.DeepEqual(node.dl.Data, dl.Data)
+}
@@ -0,0 +1,91 @@
+package rope
+
+import (
+	"testing"
+)
+
+func TestCommonPrefixSuffix(t *testing.T) {
+	build := func(parts ...string) Rope[int, SizedString] {
+		r := New[int, SizedString]()
+		for i, p := range parts {
+			r.Insert(i, i+1, SizedString(p))
+		}
+		return r
+	}
+
+	a := build("a", "b", "c", "d")
+	b := build("a", "b", "x", "d")
+	if p, s := a.CommonPrefixLen(b), a.CommonSuffixLen(b); p != 2 || s != 1 {
+		t.Errorf("expected prefix=2 suffix=1, got: %d %d", p, s)
+	}
+
+	// prefix only
+	c := build("a", "b")
+	if p, s := a.CommonPrefixLen(c), a.CommonSuffixLen(c); p != 2 || s != 0 {
+		t.Errorf("expected prefix=2 suffix=0, got: %d %d", p, s)
+	}
+
+	// suffix only, as ids here match
+	d := build("z", "b", "c", "d")
+	if p, s := a.CommonPrefixLen(d), d.CommonSuffixLen(a); p != 0 || s != 3 {
+		t.Errorf("expected prefix=0 suffix=3, got: %d %d", p, s)
+	}
+
+	// identical
+	if p, s := a.CommonPrefixLen(a), a.CommonSuffixLen(a); p != 4 || s != 4 {
+		t.Errorf("expected prefix=4 suffix=4, got: %d %d", p, s)
+	}
+
+	empty := New[int, SizedString]()
+	if p, s := a.CommonPrefixLen(empty), empty.CommonSuffixLen(a); p != 0 || s != 0 {
+		t.Errorf("expected nothing in common with empty, got: %d %d", p, s)
+	}
+}
+
+func TestCommonPrefixSuffixForeign(t *testing.T) {
+	a := buildIdRope(5)
+	b := wrappedRope[int, SizedString]{buildIdRope(5)}
+	if p, s := a.CommonPrefixLen(b), a.CommonSuffixLen(b); p != 5 || s != 5 {
+		t.Errorf("expected prefix=5 suffix=5, got: %d %d", p, s)
+	}
+
+	// the other rope's head is found via the interface, even if it's not the zero Id
+	c := NewWithSentinel[int](-1, SizedString(""))
+	c.Insert(-1, 1, "x")
+	for i := 2; i <= 5; i++ {
+		c.Insert(i-1, i, "x")
+	}
+	wc := wrappedRope[int, SizedString]{c}
+	if p, s := a.CommonPrefixLen(wc), a.CommonSuffixLen(wc); p != 5 || s != 5 {
+		t.Errorf("expected prefix=5 suffix=5 with sentinel, got: %d %d", p, s)
+	}
+}
+
+func TestSameOrder(t *testing.T) {
+	a := buildIdRope(5)
+	b := NewRoot[int, SizedString]("root")
+	for i := 1; i <= 5; i++ {
+		b.InsertInfo(i-1, i, SizedString("different"), 9)
+	}
+	if !a.SameOrder(b) || !b.SameOrder(a) {
+		t.Errorf("expected same order despite different data")
+	}
+	if a.CommonPrefixLen(b) != 0 {
+		t.Errorf("expected no common prefix with different data")
+	}
+
+	b.Delete(2, 3)
+	b.Insert(4, 3, "")
+	if a.SameOrder(b) {
+		t.Errorf("expected different order")
+	}
+	b.Delete(4, 3)
+	if a.SameOrder(b) {
+		t.Errorf("expected different count")
+	}
+
+	// other is read through the interface, so may be from elsewhere
+	if !a.SameOrder(wrappedRope[int, SizedString]{buildIdRope(5)}) {
+		t.Errorf("expected same order as wrapped rope")
+	}
+}
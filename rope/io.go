@@ -0,0 +1,62 @@
+package rope
+
+import (
+	"io"
+	"iter"
+)
+
+// WriteString writes the data of every entry of a string Rope to w, in order.
+// It returns the number of bytes written and the first error encountered.
+func WriteString[Id comparable](r Rope[Id, string], w io.Writer) (n int, err error) {
+	for _, dl := range r.Iter(r.HeadId()) {
+		var c int
+		c, err = io.WriteString(w, dl.Data)
+		n += c
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// IterChunks yields the data of a []byte Rope in chunks of chunkSize bytes, regardless of entry boundaries.
+// Only the final chunk may be shorter.
+// A chunk within a single entry aliases that entry's data; otherwise it is copied into a buffer which is reused for the next chunk.
+// Either way, chunks must not be modified or kept past the next yield.
+func IterChunks[Id comparable](r Rope[Id, []byte], chunkSize int) iter.Seq[[]byte] {
+	chunkSize = max(chunkSize, 1)
+
+	return func(yield func([]byte) bool) {
+		var buf []byte
+		for _, dl := range r.Iter(r.HeadId()) {
+			data := dl.Data
+			for len(data) != 0 {
+				if len(buf) == 0 && len(data) >= chunkSize {
+					if !yield(data[:chunkSize]) {
+						return
+					}
+					data = data[chunkSize:]
+					continue
+				}
+
+				if buf == nil {
+					buf = make([]byte, 0, chunkSize)
+				}
+				n := min(chunkSize-len(buf), len(data))
+				buf = append(buf, data[:n]...)
+				data = data[n:]
+
+				if len(buf) == chunkSize {
+					if !yield(buf) {
+						return
+					}
+					buf = buf[:0]
+				}
+			}
+		}
+
+		if len(buf) != 0 {
+			yield(buf)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package rope
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+type failingWriter struct {
+	remaining int
+}
+
+var errWriteFailed = errors.New("write failed")
+
+func (f *failingWriter) Write(b []byte) (int, error) {
+	if len(b) > f.remaining {
+		n := f.remaining
+		f.remaining = 0
+		return n, errWriteFailed
+	}
+	f.remaining -= len(b)
+	return len(b), nil
+}
+
+func TestWriteString(t *testing.T) {
+	r, _ := BuildFromSlice([]Info[int, string]{
+		{Id: 1, DataLen: DataLen[string]{Len: 5, Data: "hello"}},
+		{Id: 2, DataLen: DataLen[string]{Len: 6, Data: " there"}},
+	})
+
+	var buf bytes.Buffer
+	n, err := WriteString(r, &buf)
+	if err != nil || n != 11 || buf.String() != "hello there" {
+		t.Errorf("bad write: n=%d err=%v s=%q", n, err, buf.String())
+	}
+
+	n, err = WriteString(r, &failingWriter{remaining: 7})
+	if err != errWriteFailed || n != 7 {
+		t.Errorf("expected failure after 7 bytes, got: n=%d err=%v", n, err)
+	}
+
+	// other Rope implementations are read through the interface, from their own head
+	s := NewWithSentinel[int](-1, "")
+	s.Insert(-1, 1, "hello")
+	buf.Reset()
+	if _, err := WriteString(wrappedRope[int, string]{s}, &buf); err != nil || buf.String() != "hello" {
+		t.Errorf("bad write of wrapped rope: %q %v", buf.String(), err)
+	}
+}
+
+func TestIterChunks(t *testing.T) {
+	var entries []Info[int, []byte]
+	var all []byte
+	for i, size := range []int{1, 2, 30, 0, 7, 64, 3, 100, 5} {
+		data := bytes.Repeat([]byte{byte('a' + i)}, size)
+		entries = append(entries, Info[int, []byte]{Id: i + 1, DataLen: DataLen[[]byte]{Len: size, Data: data}})
+		all = append(all, data...)
+	}
+	r, _ := BuildFromSlice(entries)
+
+	for _, size := range []int{1, 16, 50, 1000} {
+		var got []byte
+		var count int
+		for chunk := range IterChunks(r, size) {
+			count++
+			if len(chunk) != size && len(got)+len(chunk) != len(all) {
+				t.Fatalf("size=%d: chunk=%d has wrong length=%d", size, count, len(chunk))
+			}
+			got = append(got, chunk...)
+		}
+		if !bytes.Equal(got, all) {
+			t.Errorf("size=%d: chunks didn't join to the original data", size)
+		}
+		if want := (len(all) + size - 1) / size; count != want {
+			t.Errorf("size=%d: expected %d chunks, got: %d", size, want, count)
+		}
+	}
+
+	// a chunk wholly within one entry aliases its data
+	for chunk := range IterChunks(r, 50) {
+		if &chunk[0] == &entries[5].Data[0] {
+			t.Errorf("expected first chunk to be copied")
+		}
+		break
+	}
+	var aliased int
+	for chunk := range IterChunks(r, 10) {
+		for i := range entries[7].Data {
+			if &chunk[0] == &entries[7].Data[i] {
+				aliased++
+			}
+		}
+	}
+	if aliased != 9 {
+		t.Errorf("expected chunks within a large entry to alias it, got: %d", aliased)
+	}
+
+	// other Rope implementations are read through the interface, from their own head
+	s := NewWithSentinel[int](-1, []byte(nil))
+	s.InsertInfo(-1, 1, []byte("hello"), 5)
+	var got []byte
+	for chunk := range IterChunks(wrappedRope[int, []byte]{s}, 2) {
+		got = append(got, chunk...)
+	}
+	if string(got) != "hello" {
+		t.Errorf("bad chunks of wrapped rope: %q", got)
+	}
+}
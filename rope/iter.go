@@ -0,0 +1,244 @@
+package rope
+
+import (
+	"context"
+	"iter"
+)
+
+// iterCtxEvery is how many entries IterCtx yields between checks of its context.
+const iterCtxEvery = 1024
+
+func (r *ropeImpl[Id, T]) IterUntil(afterId, untilId Id) iter.Seq2[Id, DataLen[T]] {
+	return func(yield func(Id, DataLen[T]) bool) {
+		if cmp, ok := r.Compare(afterId, untilId); !ok || cmp >= 0 {
+			return
+		}
+
+		for id, dl := range r.Iter(afterId) {
+			if !yield(id, dl) || id == untilId {
+				return
+			}
+			if _, ok := r.byId[untilId]; !ok {
+				return
+			}
+		}
+	}
+}
+
+func (r *ropeImpl[Id, T]) IterFilter(afterId Id, keep func(Id, DataLen[T]) bool) iter.Seq2[Id, DataLen[T]] {
+	return func(yield func(Id, DataLen[T]) bool) {
+		for id, dl := range r.Iter(afterId) {
+			if keep(id, dl) && !yield(id, dl) {
+				return
+			}
+		}
+	}
+}
+
+func (r *ropeImpl[Id, T]) IterCtx(ctx context.Context, afterId Id) iter.Seq2[Id, DataLen[T]] {
+	return func(yield func(Id, DataLen[T]) bool) {
+		var seen int
+		for id, dl := range r.Iter(afterId) {
+			if seen%iterCtxEvery == 0 && ctx.Err() != nil {
+				return
+			}
+			seen++
+			if !yield(id, dl) {
+				return
+			}
+		}
+	}
+}
+
+func (r *ropeImpl[Id, T]) IterFromPosition(position int, biasAfter bool) iter.Seq2[Id, DataLen[T]] {
+	return func(yield func(Id, DataLen[T]) bool) {
+		afterId, offset := r.ByPosition(position, biasAfter)
+		if offset > 0 {
+			// position is inside this entry, so include it
+			afterId = r.byId[afterId].levels[0].prev.id
+		}
+		r.Iter(afterId)(yield)
+	}
+}
+
+func (r *ropeImpl[Id, T]) IterReverse() iter.Seq2[Id, DataLen[T]] {
+	return func(yield func(Id, DataLen[T]) bool) {
+		for e := r.tail; e != &r.head; {
+			r.park(e)
+			shouldContinue := yield(e.id, e.dl)
+			update := r.unpark(e)
+
+			if !shouldContinue {
+				return
+			} else if update != e {
+				// we were deleted, so continue from the node we were parked at
+				e = update
+			} else {
+				e = e.levels[0].prev
+			}
+		}
+	}
+}
+
+func (r *ropeImpl[Id, T]) IterPosReverse(beforeId Id) iter.Seq2[int, DataLen[T]] {
+	return func(yield func(int, DataLen[T]) bool) {
+		e := r.byId[beforeId]
+		if e == nil {
+			return
+		}
+
+		// pos is the start of the last node seen, which is the end of the next one
+		pos := r.Find(beforeId) - e.dl.Len
+		version := r.version
+		next := e.levels[0].prev
+
+		for next != &r.head {
+			e = next
+			if r.version != version {
+				pos = r.Find(e.id)
+				version = r.version
+			}
+			pos -= e.dl.Len
+
+			r.park(e)
+			shouldContinue := yield(pos, e.dl)
+			update := r.unpark(e)
+
+			if !shouldContinue {
+				return
+			} else if update != e {
+				// we were deleted, so continue from the node we were parked at
+				next = update
+			} else {
+				next = e.levels[0].prev
+			}
+		}
+	}
+}
+
+func (r *ropeImpl[Id, T]) IterMeasure(afterId Id, measureIndex int) iter.Seq2[Id, int] {
+	return func(yield func(Id, int) bool) {
+		// measures may be negative, so check these directly rather than relying on PrefixMeasure's -1
+		if r.byId[afterId] == nil || measureIndex < 0 || measureIndex >= len(r.measureFns) {
+			return
+		}
+		prefix := r.PrefixMeasure(afterId, measureIndex)
+
+		version := r.version
+		for id := range r.Iter(afterId) {
+			if r.version != version {
+				// changed since the last entry, so the running total may be wrong
+				prefix = r.PrefixMeasure(id, measureIndex)
+				version = r.version
+			} else {
+				prefix += r.levelMeasure(r.byId[id], 0)[measureIndex]
+			}
+			if !yield(id, prefix) {
+				return
+			}
+		}
+	}
+}
+
+func (r *ropeImpl[Id, T]) IterContext(afterId Id, fn func(prev, curr, next Info[Id, T]) bool) {
+	for id := range r.Iter(afterId) {
+		node := r.byId[id]
+		var prev, next Info[Id, T]
+		if p := node.levels[0].prev; p != &r.head {
+			prev = r.nodeInfo(p)
+		}
+		if n := node.levels[0].next; n != nil {
+			next = r.nodeInfo(n)
+		}
+		if !fn(prev, r.nodeInfo(node), next) {
+			return
+		}
+	}
+}
+
+func (r *ropeImpl[Id, T]) IterCoalesced(afterId Id, shouldMerge func(a, b DataLen[T]) bool, merge func(a, b DataLen[T]) DataLen[T]) iter.Seq2[Id, DataLen[T]] {
+	return func(yield func(Id, DataLen[T]) bool) {
+		var runId Id
+		var run DataLen[T]
+		var started bool
+
+		for id, dl := range r.Iter(afterId) {
+			if started && shouldMerge(run, dl) {
+				run = merge(run, dl)
+				continue
+			}
+			if started && !yield(runId, run) {
+				return
+			}
+			runId, run, started = id, dl, true
+		}
+
+		if started {
+			yield(runId, run)
+		}
+	}
+}
+
+// Iterator reads entries in order, as Iter does, but keeps its state between calls to Next.
+// While it is part way through, its current entry is parked, so it is safe to use even if the Rope is modified.
+type Iterator[Id comparable, T any] struct {
+	r    *ropeImpl[Id, T]
+	node *ropeNode[Id, T] // parked here, or nil once done
+	id   Id
+	dl   DataLen[T]
+}
+
+func (r *ropeImpl[Id, T]) Iterator(afterId Id) *Iterator[Id, T] {
+	it := &Iterator[Id, T]{r: r, node: r.byId[afterId]}
+	if it.node != nil {
+		r.park(it.node)
+	}
+	return it
+}
+
+// Next moves to the next entry, returning false once there are none left.
+func (it *Iterator[Id, T]) Next() bool {
+	if it.node == nil {
+		return false
+	}
+	next := it.r.unpark(it.node).levels[0].next
+	if next == nil {
+		it.node = nil
+		return false
+	}
+	it.r.park(next)
+	it.node, it.id, it.dl = next, next.id, next.dl
+	return true
+}
+
+// Id returns the Id of the current entry, as of the last call to Next.
+func (it *Iterator[Id, T]) Id() Id {
+	return it.id
+}
+
+// Data returns the data of the current entry, as of the last call to Next.
+func (it *Iterator[Id, T]) Data() DataLen[T] {
+	return it.dl
+}
+
+// Parked returns the Id this Iterator will continue from.
+// This is the current entry, unless it was removed, in which case it is the entry that was before it.
+func (it *Iterator[Id, T]) Parked() (id Id) {
+	node := it.node
+	if node == nil {
+		return id
+	}
+	for node.iterRef.moved != nil {
+		node = node.iterRef.moved
+	}
+	return node.id
+}
+
+// Close stops this Iterator, so that Next returns false.
+// This must be called if the Iterator is abandoned early, as otherwise its entry can't be reused once removed.
+func (it *Iterator[Id, T]) Close() {
+	if it.node != nil {
+		it.r.unpark(it.node)
+		it.node = nil
+	}
+}
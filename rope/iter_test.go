@@ -0,0 +1,482 @@
+package rope
+
+import (
+	"context"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func buildIdRope(count int) Rope[int, SizedString] {
+	r := New[int, SizedString]()
+	for i := 1; i <= count; i++ {
+		r.Insert(i-1, i, "x")
+	}
+	return r
+}
+
+func TestIterUntil(t *testing.T) {
+	r := buildIdRope(10)
+
+	var got []int
+	for id := range r.IterUntil(2, 6) {
+		got = append(got, id)
+	}
+	if !reflect.DeepEqual(got, []int{3, 4, 5, 6}) {
+		t.Errorf("bad bounded iter: %v", got)
+	}
+
+	got = nil
+	for id := range r.IterUntil(6, 2) {
+		got = append(got, id)
+	}
+	if len(got) != 0 {
+		t.Errorf("expected nothing for backwards range: %v", got)
+	}
+
+	// removing untilId mid-iteration stops it
+	got = nil
+	for id := range r.IterUntil(0, 8) {
+		got = append(got, id)
+		if id == 4 {
+			r.Delete(7, 8)
+		}
+	}
+	if !reflect.DeepEqual(got, []int{1, 2, 3, 4}) {
+		t.Errorf("expected stop after untilId removed: %v", got)
+	}
+}
+
+func TestIterFilter(t *testing.T) {
+	r, _ := BuildFromSlice(randomEntries(200))
+	keep := func(id int, dl DataLen[SizedString]) bool {
+		return dl.Len >= 2
+	}
+
+	var want, got []int
+	for id, dl := range r.Iter(0) {
+		if keep(id, dl) {
+			want = append(want, id)
+		}
+	}
+	for id := range r.IterFilter(0, keep) {
+		got = append(got, id)
+	}
+
+	if len(want) == 0 || !reflect.DeepEqual(want, got) {
+		t.Errorf("filtered iter didn't match manual loop: %v vs %v", want, got)
+	}
+}
+
+func TestIterCtx(t *testing.T) {
+	r := buildIdRope(iterCtxEvery * 3)
+
+	var count int
+	for range r.IterCtx(context.Background(), 0) {
+		count++
+	}
+	if count != r.Count() {
+		t.Errorf("expected all %d entries, got: %d", r.Count(), count)
+	}
+
+	// cancelling mid-scan stops at the next check
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	count = 0
+	for range r.IterCtx(ctx, 0) {
+		count++
+		if count == 10 {
+			cancel()
+		}
+	}
+	if count != iterCtxEvery {
+		t.Errorf("expected to stop at the first check after cancel, got: %d", count)
+	}
+
+	// already cancelled yields nothing
+	for range r.IterCtx(ctx, 0) {
+		t.Fatalf("expected nothing from a cancelled context")
+	}
+}
+
+func TestIterFromPosition(t *testing.T) {
+	r := New[int, SizedString]()
+	r.Insert(0, 1, "abc")
+	r.Insert(1, 2, "")
+	r.Insert(2, 3, "de")
+	r.Insert(3, 4, "f")
+
+	collect := func(position int, biasAfter bool) (out []int) {
+		for id := range r.IterFromPosition(position, biasAfter) {
+			out = append(out, id)
+		}
+		return out
+	}
+
+	tests := []struct {
+		position  int
+		biasAfter bool
+		want      []int
+	}{
+		{0, false, []int{1, 2, 3, 4}},
+		{0, true, []int{1, 2, 3, 4}},
+		{1, false, []int{1, 2, 3, 4}}, // mid-node includes that node
+		{4, true, []int{3, 4}},
+		{3, false, []int{2, 3, 4}}, // boundary includes zero-length entries
+		{3, true, []int{3, 4}},     // or skips them
+		{6, false, nil},
+		{6, true, nil},
+	}
+	for _, tt := range tests {
+		if got := collect(tt.position, tt.biasAfter); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("IterFromPosition(%d, %v) = %v, want %v", tt.position, tt.biasAfter, got, tt.want)
+		}
+	}
+}
+
+func TestIterMeasure(t *testing.T) {
+	words := func(s SizedString) int { return len(strings.Fields(string(s))) }
+	r := NewWithMeasures[int](SizedString(""), words)
+	ids := []int{0}
+	for range 200 {
+		id := nextId()
+		r.Insert(ids[rand.IntN(len(ids))], id, SizedString("a b  cd e "[:rand.IntN(11)]))
+		ids = append(ids, id)
+	}
+
+	var count int
+	for id, prefix := range r.IterMeasure(ids[1], 0) {
+		if want := r.PrefixMeasure(id, 0); prefix != want {
+			t.Fatalf("bad prefix for id=%d: wanted=%d, got=%d", id, want, prefix)
+		}
+		count++
+	}
+	if want := r.Count() - r.RankLogN(ids[1]); count != want {
+		t.Errorf("expected %d entries, got: %d", want, count)
+	}
+
+	// changes part way through are seen
+	for id, prefix := range r.IterMeasure(0, 0) {
+		if want := r.PrefixMeasure(id, 0); prefix != want {
+			t.Fatalf("bad prefix after change for id=%d: wanted=%d, got=%d", id, want, prefix)
+		}
+		if r.RankLogN(id) == 10 {
+			r.Insert(0, nextId(), "more words here")
+		}
+	}
+
+	for range r.IterMeasure(-1, 0) {
+		t.Fatalf("expected nothing for a missing id")
+	}
+	for _, index := range []int{-1, 1} {
+		for range r.IterMeasure(0, index) {
+			t.Fatalf("expected nothing for measureIndex=%d", index)
+		}
+	}
+
+	// a negative prefix is still yielded
+	neg := NewWithMeasures[int](SizedString(""), func(s SizedString) int { return -len(s) })
+	neg.Insert(0, 1, "abc")
+	neg.Insert(1, 2, "de")
+	var got []int
+	for _, prefix := range neg.IterMeasure(1, 0) {
+		got = append(got, prefix)
+	}
+	if !slices.Equal(got, []int{-5}) {
+		t.Errorf("expected negative prefixes to be yielded, got: %v", got)
+	}
+}
+
+func TestIterContext(t *testing.T) {
+	r := buildIdRope(5)
+
+	type triple struct{ prev, curr, next int }
+	var got []triple
+	r.IterContext(0, func(prev, curr, next Info[int, SizedString]) bool {
+		got = append(got, triple{prev.Id, curr.Id, next.Id})
+		if curr.Id == 2 {
+			r.Delete(2, 3) // the next callback sees 3 is gone
+		}
+		return true
+	})
+	want := []triple{{0, 1, 2}, {1, 2, 3}, {2, 4, 5}, {4, 5, 0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bad context: %+v", got)
+	}
+
+	var first, last Info[int, SizedString]
+	r.IterContext(0, func(prev, curr, next Info[int, SizedString]) bool {
+		if curr.Id == 1 {
+			first = prev
+		}
+		last = next
+		return true
+	})
+	if first != (Info[int, SizedString]{}) || last != (Info[int, SizedString]{}) {
+		t.Errorf("expected zero Info at the ends, got: %+v %+v", first, last)
+	}
+}
+
+func TestIterCoalesced(t *testing.T) {
+	r := New[int, SizedString]()
+	for i, s := range []string{"a", "b", "long", "c", "", "d", "words", "e"} {
+		r.Insert(i, i+1, SizedString(s))
+	}
+
+	// merge runs of short entries
+	shouldMerge := func(a, b DataLen[SizedString]) bool {
+		return a.Len < 4 && b.Len <= 1
+	}
+	merge := func(a, b DataLen[SizedString]) DataLen[SizedString] {
+		return DataLen[SizedString]{Len: a.Len + b.Len, Data: a.Data + b.Data}
+	}
+
+	type run struct {
+		id   int
+		data SizedString
+	}
+	var got []run
+	for id, dl := range r.IterCoalesced(0, shouldMerge, merge) {
+		got = append(got, run{id, dl.Data})
+	}
+	want := []run{{1, "ab"}, {3, "long"}, {4, "cd"}, {7, "words"}, {8, "e"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bad coalesced iter: wanted=%v, got=%v", want, got)
+	}
+
+	// stopping early is fine, and the rope is unchanged
+	for range r.IterCoalesced(0, shouldMerge, merge) {
+		break
+	}
+	if r.Count() != 8 || r.Len() != 14 {
+		t.Errorf("rope should not change: count=%d len=%d", r.Count(), r.Len())
+	}
+}
+
+func TestIterPosReverse(t *testing.T) {
+	r, _ := BuildFromSlice(randomEntries(100))
+
+	lastId := r.LastId()
+	var want []int
+	for id, dl := range r.Iter(0) {
+		if id != lastId {
+			want = append([]int{r.Find(id) - dl.Len}, want...)
+		}
+	}
+
+	var got []int
+	for pos := range r.IterPosReverse(lastId) {
+		got = append(got, pos)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("reverse positions didn't match find: %v vs %v", want, got)
+	}
+}
+
+func TestIterPosReverseDelete(t *testing.T) {
+	r := buildIdRope(6)
+
+	var got []int
+	for pos, dl := range r.IterPosReverse(6) {
+		got = append(got, pos)
+		if pos == 3 {
+			// delete ourselves (id=4) and the one before us
+			r.Delete(2, 4)
+		}
+		if dl.Len != 1 {
+			t.Errorf("bad len: %d", dl.Len)
+		}
+	}
+
+	if !reflect.DeepEqual(got, []int{4, 3, 1, 0}) {
+		t.Errorf("bad reverse positions with delete: %v", got)
+	}
+}
+
+func TestIterReverse(t *testing.T) {
+	r := buildIdRope(10)
+
+	var got []int
+	for id := range r.IterReverse() {
+		got = append(got, id)
+		if id == 7 {
+			r.Delete(5, 7) // deletes this and the one before
+		}
+	}
+	if !reflect.DeepEqual(got, []int{10, 9, 8, 7, 5, 4, 3, 2, 1}) {
+		t.Errorf("bad reverse: %v", got)
+	}
+
+	for range New[int, SizedString]().IterReverse() {
+		t.Errorf("expected nothing from empty rope")
+	}
+}
+
+func TestIterator(t *testing.T) {
+	r := buildIdRope(5)
+	it := r.Iterator(1)
+	if it.Parked() != 1 {
+		t.Errorf("expected parked at anchor, got: %d", it.Parked())
+	}
+
+	var got []int
+	for it.Next() {
+		got = append(got, it.Id())
+		if it.Parked() != it.Id() {
+			t.Errorf("expected parked at current, got: %d", it.Parked())
+		}
+
+		if it.Id() == 3 {
+			r.Delete(2, 3)
+			if it.Parked() != 2 {
+				t.Errorf("expected parked at predecessor after delete, got: %d", it.Parked())
+			}
+			if it.Data().Data != "x" {
+				t.Errorf("expected data to still be readable, got: %+v", it.Data())
+			}
+		}
+	}
+	if !reflect.DeepEqual(got, []int{2, 3, 4, 5}) {
+		t.Errorf("bad iteration: %v", got)
+	}
+	if it.Next() {
+		t.Errorf("expected done iterator to stay done")
+	}
+
+	// closed early, so the entry it was at can be recycled
+	it = r.Iterator(0)
+	it.Next()
+	it.Close()
+	if it.Next() {
+		t.Errorf("expected closed iterator to be done")
+	}
+	if r.(*ropeImpl[int, SizedString]).head.levels[0].next.iterRef.count.Load() != 0 {
+		t.Errorf("expected nothing parked after close")
+	}
+
+	if r.Iterator(100).Next() {
+		t.Errorf("expected nothing after a missing id")
+	}
+}
+
+func BenchmarkIterReverse(b *testing.B) {
+	r := buildIdRope(100_000)
+	for b.Loop() {
+		count := 0
+		for range r.IterReverse() {
+			if count++; count == 10 {
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkIterReverseByLastId(b *testing.B) {
+	// as before the tail was kept: look up the last entry by Id, then read back from it
+	r := buildIdRope(100_000)
+	for b.Loop() {
+		last := r.LastId()
+		r.Info(last)
+		count := 1
+		for range r.IterPosReverse(last) {
+			if count++; count == 10 {
+				break
+			}
+		}
+	}
+}
+
+func TestIterConcurrentReaders(t *testing.T) {
+	r := buildIdRope(1000)
+
+	var wg sync.WaitGroup
+	counts := make([]int, 8)
+	for i := range counts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				for range r.Iter(0) {
+					counts[i]++
+				}
+				for range r.IterPosReverse(r.LastId()) {
+					counts[i]++
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, c := range counts {
+		if c != 10*(1000+999) {
+			t.Errorf("reader=%d saw wrong count=%d", i, c)
+		}
+	}
+
+	// iterators are all gone, so deleting works as normal
+	r.Delete(0, r.LastId())
+	if err := r.Validate(); err != nil {
+		t.Errorf("invalid after concurrent reads: %v", err)
+	}
+}
+
+func TestIterPoolReuse(t *testing.T) {
+	label := func(id int) SizedString { return SizedString(strconv.Itoa(id)) }
+	ops := rand.New(rand.NewPCG(1, 2))
+
+	for round := range 50 {
+		r := New[int, SizedString]()
+		for i := 1; i <= 100; i++ {
+			r.Insert(i-1, i, label(i))
+		}
+		next := 101
+
+		seen := map[int]bool{}
+		for id, dl := range r.Iter(0) {
+			if seen[id] {
+				t.Fatalf("round=%d: id=%d seen twice", round, id)
+			} else if _, ok := r.AnchorOf(id); !ok {
+				t.Fatalf("round=%d: id=%d yielded but not present", round, id)
+			} else if dl.Data != label(id) {
+				t.Fatalf("round=%d: id=%d has stale data=%q", round, id, dl.Data)
+			} else if len(seen) == 1000 {
+				t.Fatalf("round=%d: iterator didn't finish", round)
+			}
+			seen[id] = true
+
+			// insert before this entry, draining the pool
+			for range ops.IntN(5) {
+				after, _ := r.SelectLogN(ops.IntN(r.RankLogN(id)))
+				r.Insert(after, next, label(next))
+				next++
+			}
+
+			// delete this entry, then some before it, so that where the iterator would continue from may be pooled
+			prev := r.Info(id).Prev
+			r.Delete(prev, id)
+			for range ops.IntN(4) {
+				if prev == 0 {
+					break
+				}
+				before := r.Info(prev).Prev
+				r.Delete(before, prev)
+				prev = before
+			}
+
+			if err := r.Validate(); err != nil {
+				t.Fatalf("round=%d: invalid: %v", round, err)
+			}
+		}
+
+		// only entries already seen were deleted, so every original entry must have been seen
+		for id := 1; id <= 100; id++ {
+			if !seen[id] {
+				t.Fatalf("round=%d: id=%d never seen", round, id)
+			}
+		}
+	}
+}
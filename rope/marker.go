@@ -0,0 +1,104 @@
+package rope
+
+import (
+	"slices"
+)
+
+// Marker is a position in a Rope which moves as content before it is inserted or removed.
+type Marker struct {
+	pos int
+}
+
+// Position returns the current position of this Marker.
+func (m *Marker) Position() int {
+	return m.pos
+}
+
+func (r *ropeImpl[Id, T]) AddMarker(pos int) *Marker {
+	m := &Marker{pos: max(0, min(pos, r.len))}
+	r.markers = append(r.markers, m)
+	if r.txDepth != 0 {
+		r.record(func() { r.RemoveMarker(m) })
+	}
+	return m
+}
+
+func (r *ropeImpl[Id, T]) RemoveMarker(m *Marker) bool {
+	i := slices.Index(r.markers, m)
+	if i == -1 {
+		return false
+	}
+	r.markers = slices.Delete(r.markers, i, i+1)
+	if r.txDepth != 0 {
+		r.record(func() { r.markers = slices.Insert(r.markers, min(i, len(r.markers)), m) })
+	}
+	return true
+}
+
+func (r *ropeImpl[Id, T]) MarkersInRange(startPos, endPos int) (out []*Marker) {
+	for _, m := range r.markers {
+		if m.pos >= startPos && m.pos <= endPos {
+			out = append(out, m)
+		}
+	}
+	slices.SortStableFunc(out, func(a, b *Marker) int {
+		return a.pos - b.pos
+	})
+	return out
+}
+
+// hasMarkers returns whether there are any markers or annotations to move as content changes.
+func (r *ropeImpl[Id, T]) hasMarkers() bool {
+	return len(r.markers) != 0 || len(r.annotations) != 0
+}
+
+// shiftPos moves a position after pos by delta, as for shiftMarkers.
+func shiftPos(at, pos, delta int) int {
+	if at > pos {
+		return max(pos, at+delta)
+	}
+	return at
+}
+
+// shiftMarkers moves markers and annotations after pos by delta.
+// A negative delta removes content from pos, so markers within that range move to pos.
+func (r *ropeImpl[Id, T]) shiftMarkers(pos, delta int) {
+	for _, m := range r.markers {
+		m.pos = shiftPos(m.pos, pos, delta)
+	}
+	if len(r.annotations) != 0 {
+		r.shiftAnnotations(pos, delta)
+	}
+}
+
+// recordMarkers adds an undo step which restores all markers to their current positions.
+// Call this before recording any other undo steps for a change, so that it is undone last.
+// Annotations removed by the change are restored, but their OnRemove will have been called.
+func (r *ropeImpl[Id, T]) recordMarkers() {
+	if r.txDepth == 0 || !r.hasMarkers() {
+		return
+	}
+
+	markers := make([]*Marker, len(r.markers))
+	positions := make([]int, len(r.markers))
+	for i, m := range r.markers {
+		markers[i] = m
+		positions[i] = m.pos
+	}
+	annotations := slices.Clone(r.annotations)
+	ranges := make([][2]int, len(annotations))
+	for i, a := range annotations {
+		ranges[i] = [2]int{a.start, a.end}
+	}
+	r.record(func() {
+		for i, m := range markers {
+			m.pos = positions[i]
+		}
+		for i, a := range annotations {
+			a.start, a.end = ranges[i][0], ranges[i][1]
+			if !slices.Contains(r.annotations, a) {
+				r.annotations = append(r.annotations, a)
+			}
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package rope
+
+import (
+	"testing"
+)
+
+func TestMarker(t *testing.T) {
+	r := New[int, SizedString]()
+	r.Insert(0, 1, "hello")
+	r.Insert(1, 2, " there")
+
+	before := r.AddMarker(2)
+	at := r.AddMarker(5)
+	after := r.AddMarker(8)
+
+	// inserting exactly at a marker doesn't move it
+	r.Insert(1, 3, "!!")
+	if before.Position() != 2 || at.Position() != 5 || after.Position() != 10 {
+		t.Errorf("bad positions after insert: %d %d %d", before.Position(), at.Position(), after.Position())
+	}
+
+	r.Insert(0, 4, "abc")
+	if before.Position() != 5 || at.Position() != 8 || after.Position() != 13 {
+		t.Errorf("bad positions after insert at start: %d %d %d", before.Position(), at.Position(), after.Position())
+	}
+
+	// content added after all markers moves nothing
+	r.Insert(2, 5, "...")
+	if after.Position() != 13 {
+		t.Errorf("expected marker not to move, got: %d", after.Position())
+	}
+
+	// changes in length also move markers
+	r.ReplaceKeepingId(4, "a", 1, nil)
+	if before.Position() != 3 || at.Position() != 6 || after.Position() != 11 {
+		t.Errorf("bad positions after shrink: %d %d %d", before.Position(), at.Position(), after.Position())
+	}
+
+	if !r.RemoveMarker(at) || r.RemoveMarker(at) {
+		t.Errorf("expected marker to be removed once")
+	}
+	r.Delete(0, 4)
+	if at.Position() != 6 || before.Position() != 2 {
+		t.Errorf("removed marker should not move")
+	}
+
+	if m := r.AddMarker(1000); m.Position() != r.Len() {
+		t.Errorf("expected marker clamped to len, got: %d", m.Position())
+	}
+}
+
+func TestMarkerReplaceKeepingId(t *testing.T) {
+	r := New[int, SizedString]()
+	r.Insert(0, 1, "hello")
+	r.Insert(1, 2, " there")
+	r.Insert(2, 3, "!")
+
+	atStart := r.AddMarker(5)
+	inside := r.AddMarker(9) // two back from the end of " there"
+	atEnd := r.AddMarker(11)
+	after := r.AddMarker(12)
+
+	keep := func(old int) int { return old }
+	if err := r.ReplaceKeepingId(2, "abcd", 4, keep); err != nil {
+		t.Fatalf("couldn't replace: %v", err)
+	}
+	if atStart.Position() != 5 || inside.Position() != 7 || atEnd.Position() != 9 || after.Position() != 10 {
+		t.Errorf("bad positions after shrink: %d %d %d %d", atStart.Position(), inside.Position(), atEnd.Position(), after.Position())
+	}
+
+	// remapped offsets past the start of the entry are clamped to it
+	double := func(old int) int { return old * 2 }
+	if err := r.ReplaceKeepingId(2, "ab", 2, double); err != nil {
+		t.Fatalf("couldn't replace: %v", err)
+	}
+	if atStart.Position() != 5 || inside.Position() != 5 || atEnd.Position() != 7 || after.Position() != 8 {
+		t.Errorf("bad positions after remap: %d %d %d %d", atStart.Position(), inside.Position(), atEnd.Position(), after.Position())
+	}
+
+	r.Transaction(func(tx Rope[int, SizedString]) error {
+		tx.ReplaceKeepingId(2, "abcdef", 6, double)
+		return errAbort
+	})
+	if atStart.Position() != 5 || inside.Position() != 5 || atEnd.Position() != 7 || after.Position() != 8 {
+		t.Errorf("expected markers restored after rollback: %d %d %d %d", atStart.Position(), inside.Position(), atEnd.Position(), after.Position())
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+}
+
+func TestMarkerTransaction(t *testing.T) {
+	r := buildIdRope(10)
+	m := r.AddMarker(6)
+
+	r.Transaction(func(tx Rope[int, SizedString]) error {
+		tx.Delete(2, 8)
+		tx.Insert(0, 100, "hello")
+		tx.ReplaceByPosition(0, 3, 101, "x", 1)
+		return errAbort
+	})
+	if m.Position() != 6 {
+		t.Errorf("expected marker restored after rollback, got: %d", m.Position())
+	}
+
+	// adding and removing markers is also undone
+	var added *Marker
+	r.Transaction(func(tx Rope[int, SizedString]) error {
+		added = tx.AddMarker(3)
+		tx.RemoveMarker(m)
+		tx.Insert(0, 100, "hello")
+		return errAbort
+	})
+	if got := r.MarkersInRange(0, r.Len()); len(got) != 1 || got[0] != m || m.Position() != 6 {
+		t.Errorf("expected only the original marker after rollback, got: %v", got)
+	}
+	if r.RemoveMarker(added) {
+		t.Errorf("expected added marker to be gone")
+	}
+}
+
+func TestMarkersInRange(t *testing.T) {
+	r := buildIdRope(10)
+	var markers []*Marker
+	for _, pos := range []int{9, 1, 4, 5, 6, 10} {
+		markers = append(markers, r.AddMarker(pos))
+	}
+
+	got := r.MarkersInRange(4, 6)
+	if len(got) != 3 || got[0] != markers[2] || got[1] != markers[3] || got[2] != markers[4] {
+		t.Errorf("bad markers in range: %v", got)
+	}
+
+	// markers within the deleted range clamp to its start, and survive
+	r.Delete(3, 7) // removes [3,7)
+	for i, want := range []int{5, 1, 3, 3, 3, 6} {
+		if markers[i].Position() != want {
+			t.Errorf("bad position for marker=%d: wanted=%d, got=%d", i, want, markers[i].Position())
+		}
+	}
+
+	got = r.MarkersInRange(3, 3)
+	if len(got) != 3 {
+		t.Errorf("expected clamped markers in range, got: %v", got)
+	}
+	if got := r.MarkersInRange(6, 100); len(got) != 1 || got[0] != markers[5] {
+		t.Errorf("expected end marker in range, got: %v", got)
+	}
+	if got := r.MarkersInRange(7, 100); len(got) != 0 {
+		t.Errorf("expected no markers, got: %v", got)
+	}
+}
@@ -0,0 +1,118 @@
+package rope
+
+// measured returns whether this Rope maintains custom measures, which are otherwise always zero.
+func (r *ropeImpl[Id, T]) measured() bool {
+	return r.nodeMeasures != nil
+}
+
+// levelMeasure returns the custom measures covered by node at level h, which are zero if unset.
+func (r *ropeImpl[Id, T]) levelMeasure(node *ropeNode[Id, T], h int) measures {
+	if m := r.nodeMeasures[node]; h < len(m) {
+		return m[h]
+	}
+	return measures{}
+}
+
+// levelMeasures returns the custom measures covered by every level of node, sized to match its levels.
+// These may be updated in place. Only call this if measured.
+func (r *ropeImpl[Id, T]) levelMeasures(node *ropeNode[Id, T]) []measures {
+	m := r.nodeMeasures[node]
+	if len(m) != len(node.levels) {
+		if len(m) < len(node.levels) {
+			m = append(m, make([]measures, len(node.levels)-len(m))...)
+		} else {
+			m = m[:len(node.levels)]
+		}
+		r.nodeMeasures[node] = m
+	}
+	return m
+}
+
+// prefixMeasures returns the custom measures of every node up to and including node.
+func (r *ropeImpl[Id, T]) prefixMeasures(node *ropeNode[Id, T]) measures {
+	out := r.levelMeasure(node, 0)
+	for node != &r.head {
+		link := len(node.levels) - 1
+		node = node.levels[link].prev
+		out = out.add(r.levelMeasure(node, link))
+	}
+	return out
+}
+
+// seekMeasures fills out with the custom measures from the start of each seek node up to the end of after.
+// This walks back from after in the same way that splice seeks.
+func (r *ropeImpl[Id, T]) seekMeasures(seek []ropeSeek[Id, T], after *ropeNode[Id, T], out []measures) {
+	node, m := after, r.levelMeasure(after, 0)
+	i := 0
+	for {
+		for ; i < len(node.levels) && i < len(seek); i++ {
+			out[i] = m
+		}
+		if i == len(seek) {
+			return
+		}
+		link := i - 1
+		node = node.levels[link].prev
+		m = m.add(r.levelMeasure(node, link))
+	}
+}
+
+// removeMeasures updates custom measures for the removal of e, which was just unlinked after the seek.
+func (r *ropeImpl[Id, T]) removeMeasures(seek []ropeSeek[Id, T], e *ropeNode[Id, T]) {
+	em := r.levelMeasure(e, 0)
+	r.measure = r.measure.sub(em)
+
+	var last *ropeNode[Id, T]
+	var lm []measures
+	for j := range seek {
+		if seek[j].node != last {
+			last = seek[j].node
+			lm = r.levelMeasures(last)
+		}
+		if j >= len(e.levels) {
+			lm[j] = lm[j].sub(em)
+		} else {
+			lm[j] = lm[j].add(r.levelMeasure(e, j)).sub(em)
+		}
+	}
+}
+
+// insertMeasures updates custom measures for node, which was just linked after the seek.
+// The seek is as it was before node was linked, but the head may have grown.
+func (r *ropeImpl[Id, T]) insertMeasures(seek []ropeSeek[Id, T], after, node *ropeNode[Id, T]) {
+	var stack [maxHeight]measures
+	sm := stack[:]
+	if len(seek) > maxHeight {
+		sm = make([]measures, len(seek))
+	}
+	sm = sm[:len(seek)]
+	r.seekMeasures(seek, after, sm)
+
+	var prefix measures
+	if len(node.levels) > len(seek) {
+		prefix = r.prefixMeasures(after)
+	}
+
+	m := r.measureOf(node.dl.Data)
+	nm := r.levelMeasures(node)
+	var last *ropeNode[Id, T]
+	var lm []measures
+	for i := range seek {
+		if seek[i].node != last {
+			last = seek[i].node
+			lm = r.levelMeasures(last)
+		}
+		if i < len(nm) {
+			nm[i] = m.add(lm[i]).sub(sm[i])
+			lm[i] = sm[i]
+		} else {
+			lm[i] = lm[i].add(m)
+		}
+	}
+	for i := len(seek); i < len(nm); i++ {
+		// the head grew, so it covers everything before node
+		r.levelMeasures(&r.head)[i] = prefix
+		nm[i] = r.measure.sub(prefix).add(m)
+	}
+	r.measure = r.measure.add(m)
+}
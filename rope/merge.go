@@ -0,0 +1,26 @@
+package rope
+
+func (r *ropeImpl[Id, T]) MergeOrdered(other Rope[Id, T], less func(a, b Id) bool) (Rope[Id, T], error) {
+	entries := make([]Info[Id, T], 0, r.totalCount()+other.Count())
+	add := func(node *ropeNode[Id, T]) *ropeNode[Id, T] {
+		entries = append(entries, Info[Id, T]{Id: node.id, DataLen: node.dl})
+		return node.levels[0].next
+	}
+
+	// other is read via its Iter, so it need not be from this package
+	a := r.head.levels[0].next
+	for id, dl := range other.Iter(other.HeadId()) {
+		for a != nil && !less(id, a.id) {
+			a = add(a)
+		}
+		entries = append(entries, Info[Id, T]{Id: id, DataLen: dl})
+	}
+	for ; a != nil; a = add(a) {
+	}
+
+	out, err := r.buildLike(entries)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,64 @@
+package rope
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMergeOrdered(t *testing.T) {
+	build := func(stamps ...int) Rope[int, SizedString] {
+		r := New[int, SizedString]()
+		var prev int
+		for _, s := range stamps {
+			r.Insert(prev, s, "x")
+			prev = s
+		}
+		return r
+	}
+	less := func(a, b int) bool { return a < b }
+
+	a := build(1, 4, 5, 9)
+	b := build(2, 3, 6, 10, 11)
+
+	out, err := a.MergeOrdered(b, less)
+	if err != nil {
+		t.Fatalf("couldn't merge: %v", err)
+	}
+	if err := out.Validate(); err != nil {
+		t.Fatalf("invalid merged rope: %v", err)
+	}
+
+	expected := []int{1, 2, 3, 4, 5, 6, 9, 10, 11}
+	var got []int
+	for id := range out.Iter(0) {
+		got = append(got, id)
+	}
+	if !slices.Equal(got, expected) {
+		t.Errorf("bad merge order: wanted=%v, got=%v", expected, got)
+	}
+	if out.Len() != 9 || out.LastId() != 11 || out.Find(6) != 6 {
+		t.Errorf("bad merged rope: len=%d lastId=%d", out.Len(), out.LastId())
+	}
+
+	// inputs are unchanged
+	if a.Count() != 4 || b.Count() != 5 {
+		t.Errorf("inputs should not change: %d %d", a.Count(), b.Count())
+	}
+
+	// merging with empty is a copy
+	copied, _ := a.MergeOrdered(New[int, SizedString](), less)
+	if copied.CommonPrefixLen(a) != 4 {
+		t.Errorf("expected merge with empty to copy")
+	}
+
+	_, err = a.MergeOrdered(build(3, 5), less)
+	if err != ErrIdExists {
+		t.Errorf("expected ErrIdExists, got: %v", err)
+	}
+
+	// other is read through the interface, so may be from elsewhere
+	wrapped, err := a.MergeOrdered(wrappedRope[int, SizedString]{b}, less)
+	if err != nil || wrapped.CommonPrefixLen(out) != 9 {
+		t.Errorf("bad merge with wrapped rope: %v", err)
+	}
+}
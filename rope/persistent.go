@@ -0,0 +1,410 @@
+package rope
+
+import (
+	"cmp"
+	"hash/maphash"
+	"iter"
+	"math/rand/v2"
+	"slices"
+)
+
+// Persistent is an immutable rope: every change returns a new Persistent, leaving the original untouched.
+// Versions share all unchanged structure, so keeping many of them is cheap.
+// Unlike Rope, it is a balanced tree rather than a skip list, as a skip list's back links can't be shared.
+// Persistent values are safe to read from many goroutines.
+type Persistent[Id comparable, T any] interface {
+	// Returns the total sum of the parts of the rope. O(1).
+	Len() int
+	// Returns the number of parts here. O(1).
+	Count() int
+	// Finds the position after the given Id, or -1 if it is not here.
+	// This lookup costs ~O(logn).
+	Find(id Id) int
+	// Iter reads from after the given Id.
+	Iter(afterId Id) iter.Seq2[Id, DataLen[T]]
+	// Splice is as Rope's Splice, but returns the new version rather than changing this one.
+	// Costs ~O(logn+m), where m is the number of nodes being deleted.
+	// Inserting occasionally relabels nearby entries to make room, which is amortized over many inserts.
+	Splice(afterId Id, deleteUntilId *Id, insertId *Id, data T) (next Persistent[Id, T], removed []Removed[Id, T], err error)
+	// Insert adds a new entry after afterId, returning the new version. Convenience wrapper around Splice.
+	Insert(afterId Id, newId Id, data T) (Persistent[Id, T], error)
+	// Delete removes entries from after afterId until untilId, returning the new version. Convenience wrapper around Splice.
+	Delete(afterId Id, untilId Id) (Persistent[Id, T], []Removed[Id, T], error)
+}
+
+// NewPersistent builds a new, empty Persistent[Id, T].
+// Lengths of inserted data are found via Sizer, as for NewRoot.
+func NewPersistent[Id comparable, T any]() Persistent[Id, T] {
+	return &persistentImpl[Id, T]{
+		shared: &persistentShared[Id, T]{
+			seed:  maphash.MakeSeed(),
+			lenFn: sizerLenFn[T](),
+		},
+	}
+}
+
+// persistentShared is the same for every version derived from a NewPersistent call.
+type persistentShared[Id comparable, T any] struct {
+	seed  maphash.Seed
+	lenFn func(T) int
+}
+
+type persistentImpl[Id comparable, T any] struct {
+	shared *persistentShared[Id, T]
+	root   *pNode[Id, T] // entries in order, by key
+	index  *pTrie[Id]    // each Id to its key
+}
+
+// pNode is an immutable node of a treap, ordered by key.
+// Keys are labels in [1,pKeySpan), spread out so that a new key usually fits between two neighbors.
+// When one doesn't, a nearby range of keys is relabelled to make room, as in list labeling.
+type pNode[Id comparable, T any] struct {
+	key         uint64
+	id          Id
+	dl          DataLen[T]
+	priority    uint64
+	left, right *pNode[Id, T]
+	size        int // total length of this subtree
+	count       int // total nodes in this subtree
+}
+
+// pKeySpan is one past the largest key. The zero key is before every entry, and belongs to the zero Id.
+const pKeySpan = 1 << 62
+
+func (n *pNode[Id, T]) sizes() (size, count int) {
+	if n == nil {
+		return 0, 0
+	}
+	return n.size, n.count
+}
+
+// with returns a copy of n with new children.
+func (n *pNode[Id, T]) with(left, right *pNode[Id, T]) *pNode[Id, T] {
+	out := *n
+	out.left, out.right = left, right
+	ls, lc := left.sizes()
+	rs, rc := right.sizes()
+	out.size = ls + rs + n.dl.Len
+	out.count = lc + rc + 1
+	return &out
+}
+
+// pSplit splits n into nodes with keys up to and including key, and those after it.
+func pSplit[Id comparable, T any](n *pNode[Id, T], key uint64) (left, right *pNode[Id, T]) {
+	if n == nil {
+		return nil, nil
+	}
+	if n.key <= key {
+		l, r := pSplit(n.right, key)
+		return n.with(n.left, l), r
+	}
+	l, r := pSplit(n.left, key)
+	return l, n.with(r, n.right)
+}
+
+// pMerge joins two treaps, where every key in left is before every key in right.
+func pMerge[Id comparable, T any](left, right *pNode[Id, T]) *pNode[Id, T] {
+	if left == nil {
+		return right
+	} else if right == nil {
+		return left
+	}
+	if left.priority > right.priority {
+		return left.with(left.left, pMerge(left.right, right))
+	}
+	return right.with(pMerge(left, right.left), right.right)
+}
+
+// pCount returns the number of nodes with keys up to and including key.
+func pCount[Id comparable, T any](n *pNode[Id, T], key uint64) (count int) {
+	for n != nil {
+		if n.key <= key {
+			_, c := n.left.sizes()
+			count += c + 1
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+	return count
+}
+
+// pAppend appends every node of n to out, in order.
+func pAppend[Id comparable, T any](out []*pNode[Id, T], n *pNode[Id, T]) []*pNode[Id, T] {
+	for n != nil {
+		out = append(pAppend(out, n.left), n)
+		n = n.right
+	}
+	return out
+}
+
+func (p *persistentImpl[Id, T]) Len() int {
+	size, _ := p.root.sizes()
+	return size
+}
+
+func (p *persistentImpl[Id, T]) Count() int {
+	_, count := p.root.sizes()
+	return count
+}
+
+// keyOf returns the key for the given Id, where the zero Id has the zero key.
+func (p *persistentImpl[Id, T]) keyOf(id Id) (uint64, bool) {
+	var zeroId Id
+	if id == zeroId {
+		return 0, true
+	}
+	return p.index.get(maphash.Comparable(p.shared.seed, id), id)
+}
+
+func (p *persistentImpl[Id, T]) Find(id Id) int {
+	key, ok := p.keyOf(id)
+	if !ok {
+		return -1
+	}
+
+	var pos int
+	for n := p.root; n != nil; {
+		if n.key <= key {
+			size, _ := n.left.sizes()
+			pos += size + n.dl.Len
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+	return pos
+}
+
+func (p *persistentImpl[Id, T]) Iter(afterId Id) iter.Seq2[Id, DataLen[T]] {
+	return func(yield func(Id, DataLen[T]) bool) {
+		key, ok := p.keyOf(afterId)
+		if !ok {
+			return
+		}
+		pWalk(p.root, key, yield)
+	}
+}
+
+// pWalk yields every node with a key after the given key in order, returning false if yield did.
+func pWalk[Id comparable, T any](n *pNode[Id, T], after uint64, yield func(Id, DataLen[T]) bool) bool {
+	for n != nil {
+		if n.key <= after {
+			n = n.right
+			continue
+		}
+		if !pWalk(n.left, after, yield) || !yield(n.id, n.dl) {
+			return false
+		}
+		n = n.right
+	}
+	return true
+}
+
+func (p *persistentImpl[Id, T]) Splice(afterId Id, deleteUntilId *Id, insertId *Id, data T) (next Persistent[Id, T], removed []Removed[Id, T], err error) {
+	afterKey, ok := p.keyOf(afterId)
+	if !ok {
+		return nil, nil, ErrBadAnchor
+	}
+
+	var length int
+	if insertId != nil {
+		if _, exists := p.keyOf(*insertId); exists {
+			return nil, nil, ErrIdExists
+		}
+		if p.shared.lenFn != nil {
+			length = p.shared.lenFn(data)
+		}
+		if length < 0 {
+			return nil, nil, ErrNegativeLength
+		}
+	}
+
+	out := &persistentImpl[Id, T]{shared: p.shared, index: p.index}
+	left, right := pSplit(p.root, afterKey)
+
+	if deleteUntilId != nil && *deleteUntilId != afterId {
+		untilKey, ok := p.keyOf(*deleteUntilId)
+		if !ok || untilKey <= afterKey {
+			return nil, nil, ErrUntilNotAfter
+		}
+
+		var middle *pNode[Id, T]
+		middle, right = pSplit(right, untilKey)
+		pWalk(middle, afterKey, func(id Id, dl DataLen[T]) bool {
+			removed = append(removed, Removed[Id, T]{Id: id, Len: dl.Len, Data: dl.Data})
+			out.index = out.index.del(maphash.Comparable(p.shared.seed, id), id, 0)
+			return true
+		})
+	}
+
+	if insertId == nil {
+		out.root = pMerge(left, right)
+		return out, removed, nil
+	}
+
+	nextKey := uint64(pKeySpan)
+	if right != nil {
+		first := right
+		for first.left != nil {
+			first = first.left
+		}
+		nextKey = first.key
+	}
+
+	n := &pNode[Id, T]{
+		id:       *insertId,
+		dl:       DataLen[T]{Len: length, Data: data},
+		priority: rand.Uint64(),
+	}
+	if nextKey-afterKey > 1 {
+		n.key = afterKey + (nextKey-afterKey)/2
+		out.root = pMerge(pMerge(left, n.with(nil, nil)), right)
+		out.index = out.index.set(pEntry[Id]{hash: maphash.Comparable(p.shared.seed, n.id), id: n.id, key: n.key}, 0)
+	} else {
+		out.root = pMerge(left, right)
+		out.relabel(afterKey, n)
+	}
+	return out, removed, nil
+}
+
+// relabel adds n after afterKey when no key fits there, by spreading out the keys in a range around it.
+// This picks the smallest aligned range of 1<<bits keys holding at most 1<<(bits/2) entries, so that ranges are
+// sparser the smaller they are, and the amortized number of entries relabelled per insert stays ~O(logn).
+func (p *persistentImpl[Id, T]) relabel(afterKey uint64, n *pNode[Id, T]) {
+	var base, span uint64
+	for bits := 1; ; bits++ {
+		span = 1 << bits
+		base = afterKey &^ (span - 1)
+		count := pCount(p.root, base+span-1)
+		if base > 0 {
+			count -= pCount(p.root, base-1)
+		}
+		if span == pKeySpan || count+1 <= 1<<(bits/2) {
+			break
+		}
+	}
+
+	var left, middle, right *pNode[Id, T]
+	middle, right = pSplit(p.root, base+span-1)
+	if base > 0 {
+		left, middle = pSplit(middle, base-1)
+	}
+
+	nodes := pAppend(nil, middle)
+	at, _ := slices.BinarySearchFunc(nodes, afterKey+1, func(n *pNode[Id, T], key uint64) int {
+		return cmp.Compare(n.key, key)
+	})
+	nodes = slices.Insert(nodes, at, n)
+
+	gap := span / uint64(len(nodes)+1)
+	middle = nil
+	for i, node := range nodes {
+		relabelled := *node
+		relabelled.key = base + uint64(i+1)*gap
+		middle = pMerge(middle, relabelled.with(nil, nil))
+
+		p.index = p.index.set(pEntry[Id]{hash: maphash.Comparable(p.shared.seed, node.id), id: node.id, key: relabelled.key}, 0)
+	}
+	p.root = pMerge(pMerge(left, middle), right)
+}
+
+func (p *persistentImpl[Id, T]) Insert(afterId Id, newId Id, data T) (Persistent[Id, T], error) {
+	next, _, err := p.Splice(afterId, nil, &newId, data)
+	return next, err
+}
+
+func (p *persistentImpl[Id, T]) Delete(afterId Id, untilId Id) (Persistent[Id, T], []Removed[Id, T], error) {
+	return p.Splice(afterId, &untilId, nil, *new(T))
+}
+
+// pTrie is an immutable hash trie from Id to key.
+// Branches have children; leaves have entries, which all share the same hash.
+type pTrie[Id comparable] struct {
+	children *[pTrieWidth]*pTrie[Id]
+	entries  []pEntry[Id]
+}
+
+const (
+	pTrieBits  = 4
+	pTrieWidth = 1 << pTrieBits
+)
+
+type pEntry[Id comparable] struct {
+	hash uint64
+	id   Id
+	key  uint64
+}
+
+func (t *pTrie[Id]) get(hash uint64, id Id) (uint64, bool) {
+	for shift := 0; t != nil; shift += pTrieBits {
+		if t.children == nil {
+			for _, e := range t.entries {
+				if e.id == id {
+					return e.key, true
+				}
+			}
+			return 0, false
+		}
+		t = t.children[(hash>>shift)%pTrieWidth]
+	}
+	return 0, false
+}
+
+// set returns a copy of this trie with the given entry, replacing any for the same Id.
+func (t *pTrie[Id]) set(e pEntry[Id], shift int) *pTrie[Id] {
+	if t == nil {
+		return &pTrie[Id]{entries: []pEntry[Id]{e}}
+	}
+
+	if t.children == nil {
+		if t.entries[0].hash == e.hash {
+			entries := slices.Clone(t.entries)
+			if i := slices.IndexFunc(entries, func(old pEntry[Id]) bool { return old.id == e.id }); i != -1 {
+				entries[i] = e
+			} else {
+				entries = append(entries, e)
+			}
+			return &pTrie[Id]{entries: entries}
+		}
+		// push this leaf down into a new branch, where the hashes will eventually differ
+		branch := &pTrie[Id]{children: new([pTrieWidth]*pTrie[Id])}
+		branch.children[(t.entries[0].hash>>shift)%pTrieWidth] = t
+		return branch.set(e, shift)
+	}
+
+	children := *t.children
+	index := (e.hash >> shift) % pTrieWidth
+	children[index] = children[index].set(e, shift+pTrieBits)
+	return &pTrie[Id]{children: &children}
+}
+
+// del returns a copy of this trie without the given Id, or nil if it is now empty.
+func (t *pTrie[Id]) del(hash uint64, id Id, shift int) *pTrie[Id] {
+	if t == nil {
+		return nil
+	}
+
+	if t.children == nil {
+		entries := make([]pEntry[Id], 0, len(t.entries))
+		for _, e := range t.entries {
+			if e.id != id {
+				entries = append(entries, e)
+			}
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		return &pTrie[Id]{entries: entries}
+	}
+
+	children := *t.children
+	index := (hash >> shift) % pTrieWidth
+	children[index] = children[index].del(hash, id, shift+pTrieBits)
+	for _, c := range children {
+		if c != nil {
+			return &pTrie[Id]{children: &children}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package rope
+
+import (
+	"math/bits"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func TestPersistent(t *testing.T) {
+	type version struct {
+		p       Persistent[int, SizedString]
+		entries []Info[int, SizedString]
+	}
+	versions := []version{{p: NewPersistent[int, SizedString]()}}
+
+	for range 2000 {
+		// derive from any earlier version, so versions branch
+		from := versions[rand.IntN(len(versions))]
+		entries := from.entries
+
+		var afterId int
+		at := rand.IntN(len(entries) + 1)
+		if at > 0 {
+			afterId = entries[at-1].Id
+		}
+
+		if at < len(entries) && rand.IntN(4) == 0 {
+			until := at + rand.IntN(min(4, len(entries)-at))
+			next, removed, err := from.p.Delete(afterId, entries[until].Id)
+			if err != nil {
+				t.Fatalf("couldn't delete: %v", err)
+			}
+			if len(removed) != until-at+1 || removed[0].Id != entries[at].Id {
+				t.Fatalf("bad removed: %+v", removed)
+			}
+			updated := append(append([]Info[int, SizedString]{}, entries[:at]...), entries[until+1:]...)
+			versions = append(versions, version{next, updated})
+			continue
+		}
+
+		newId := nextId()
+		data := SizedString("abc"[:rand.IntN(4)])
+		next, err := from.p.Insert(afterId, newId, data)
+		if err != nil {
+			t.Fatalf("couldn't insert: %v", err)
+		}
+		e := Info[int, SizedString]{Id: newId, DataLen: DataLen[SizedString]{Len: len(data), Data: data}}
+		updated := append(append(append([]Info[int, SizedString]{}, entries[:at]...), e), entries[at:]...)
+		versions = append(versions, version{next, updated})
+	}
+
+	// every version still matches what it held when created
+	for i, v := range versions {
+		var got []Info[int, SizedString]
+		for id, dl := range v.p.Iter(0) {
+			got = append(got, Info[int, SizedString]{Id: id, DataLen: dl})
+		}
+		if len(got) != len(v.entries) || (len(got) != 0 && !reflect.DeepEqual(got, v.entries)) {
+			t.Fatalf("version=%d changed: wanted=%+v, got=%+v", i, v.entries, got)
+		}
+
+		var pos int
+		for _, e := range v.entries {
+			pos += e.Len
+			if at := v.p.Find(e.Id); at != pos {
+				t.Fatalf("version=%d bad find: wanted=%d, got=%d", i, pos, at)
+			}
+		}
+		if v.p.Len() != pos || v.p.Count() != len(v.entries) {
+			t.Fatalf("version=%d bad len=%d or count=%d", i, v.p.Len(), v.p.Count())
+		}
+	}
+}
+
+func TestPersistentErrors(t *testing.T) {
+	p := NewPersistent[int, SizedString]()
+	p, _ = p.Insert(0, 1, "hello")
+	p, _ = p.Insert(1, 2, " there")
+
+	if _, err := p.Insert(5, 3, "x"); err != ErrBadAnchor {
+		t.Errorf("expected ErrBadAnchor, got: %v", err)
+	}
+	if _, err := p.Insert(0, 2, "x"); err != ErrIdExists {
+		t.Errorf("expected ErrIdExists, got: %v", err)
+	}
+	if _, _, err := p.Delete(2, 1); err != ErrUntilNotAfter {
+		t.Errorf("expected ErrUntilNotAfter, got: %v", err)
+	}
+
+	// iterating partway
+	var ids []int
+	for id := range p.Iter(1) {
+		ids = append(ids, id)
+	}
+	if !reflect.DeepEqual(ids, []int{2}) {
+		t.Errorf("bad iter after id: %v", ids)
+	}
+	if p.Find(3) != -1 {
+		t.Errorf("expected missing id to have no position")
+	}
+}
+
+func TestPersistentAppendPrepend(t *testing.T) {
+	p, _ := NewPersistent[int, SizedString]().Insert(0, -1, "x")
+	first, last := -1, -1
+	for i := range 10_000 {
+		front, back := 2*i+1, 2*i+2
+		p, _ = p.Insert(0, front, "a")
+		p, _ = p.Insert(last, back, "b")
+		first, last = front, back
+	}
+
+	if p.Count() != 20_001 || p.Find(first) != 1 || p.Find(last) != 20_001 {
+		t.Fatalf("bad count=%d or first/last position: %d %d", p.Count(), p.Find(first), p.Find(last))
+	}
+	var pos int
+	for id := range p.Iter(0) {
+		pos++
+		if p.Find(id) != pos {
+			t.Fatalf("bad find for id=%d: wanted=%d, got=%d", id, pos, p.Find(id))
+		}
+	}
+
+	// keys are relabelled rather than growing, so the treap stays balanced
+	var depth func(n *pNode[int, SizedString]) int
+	depth = func(n *pNode[int, SizedString]) int {
+		if n == nil {
+			return 0
+		}
+		return 1 + max(depth(n.left), depth(n.right))
+	}
+	if d, limit := depth(p.(*persistentImpl[int, SizedString]).root), 4*bits.Len(uint(p.Count())); d > limit {
+		t.Errorf("expected depth at most %d, got: %d", limit, d)
+	}
+}
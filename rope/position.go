@@ -0,0 +1,133 @@
+package rope
+
+import (
+	"math/bits"
+	"slices"
+)
+
+func (r *ropeImpl[Id, T]) ByPositionG(position int, g Gravity) (id Id, offset int) {
+	switch g {
+	case GravityLeft:
+		return r.ByPosition(position, false)
+	case GravityRight:
+		id, offset = r.ByPosition(position, true)
+		node := r.byId[id]
+		if offset != 0 && offset == node.dl.Len {
+			// at the start of the next entry with length, so step back to the last entry ending here
+			return node.levels[0].prev.id, 0
+		}
+		return id, offset
+	default:
+		return r.ByPosition(position, true)
+	}
+}
+
+func (r *ropeImpl[Id, T]) CaretAt(position int) (anchorId Id, offset int) {
+	return r.ByPositionG(min(max(position, 0), r.len), GravityRight)
+}
+
+func (r *ropeImpl[Id, T]) PositionOf(id Id, offset int) (int, bool) {
+	e := r.byId[id]
+	if e == nil || offset < 0 || offset > e.dl.Len {
+		return -1, false
+	}
+	return r.Find(id) - offset, true
+}
+
+func (r *ropeImpl[Id, T]) IsBoundary(position int) bool {
+	if position < 0 || position > r.len {
+		return false
+	} else if position == 0 || position == r.len {
+		return true
+	}
+	_, offset := r.ByPosition(position, false)
+	return offset == 0
+}
+
+func (r *ropeImpl[Id, T]) ByPositions(positions []int, biasAfter bool) []struct {
+	Id     Id
+	Offset int
+} {
+	out := make([]struct {
+		Id     Id
+		Offset int
+	}, len(positions))
+
+	// walk in order of position, but write results in the original order
+	order := make([]int, 0, len(positions))
+	for i, p := range positions {
+		switch {
+		case p < 0 || p > r.len:
+			out[i].Id = r.head.id
+		case (!biasAfter && p == 0) || (biasAfter && p == r.len):
+			out[i].Id, out[i].Offset = r.ByPosition(p, biasAfter)
+		default:
+			order = append(order, i)
+		}
+	}
+	if len(order) == 0 {
+		return out
+	}
+
+	// for only a few queries, it's cheaper to descend for each
+	if len(order)*bits.Len(uint(r.Count())) < r.Count() {
+		for _, i := range order {
+			out[i].Id, out[i].Offset = r.ByPosition(positions[i], biasAfter)
+		}
+		return out
+	}
+
+	slices.SortFunc(order, func(a, b int) int {
+		return positions[a] - positions[b]
+	})
+
+	// find the first entry ending at (or with biasAfter, after) each position
+	e := &r.head
+	var end int
+	for _, i := range order {
+		p := positions[i]
+		for end < p || (biasAfter && end == p) {
+			e = e.levels[0].next
+			end += e.dl.Len
+		}
+		out[i].Id, out[i].Offset = e.id, end-p
+	}
+	return out
+}
+
+func (r *ropeImpl[Id, T]) ByMeasure(measureIndex, value int) (id Id, offset int) {
+	if value <= 0 {
+		return r.head.id, 0
+	} else if value > r.measure[measureIndex] {
+		return r.tail.id, 0
+	}
+
+	e := &r.head
+	for h := r.height - 1; h >= 0; h-- {
+		for value > r.levelMeasure(e, h)[measureIndex] && e.levels[h].next != nil {
+			value -= r.levelMeasure(e, h)[measureIndex]
+			e = e.levels[h].next
+		}
+	}
+	return e.id, r.levelMeasure(e, 0)[measureIndex] - value
+}
+
+func (r *ropeImpl[Id, T]) PrefixMeasure(id Id, measureIndex int) int {
+	e := r.byId[id]
+	if e == nil {
+		return -1
+	}
+	return r.prefixMeasures(e)[measureIndex]
+}
+
+func (r *ropeImpl[Id, T]) ByWeight(w int) (id Id, offset int) {
+	return r.ByMeasure(0, w)
+}
+
+func (r *ropeImpl[Id, T]) PrefixWeight(id Id) int {
+	return r.PrefixMeasure(id, 0)
+}
+
+func (r *ropeImpl[Id, T]) TotalWeight() int {
+	return r.measure[0]
+}
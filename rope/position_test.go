@@ -0,0 +1,371 @@
+package rope
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestByPositionG(t *testing.T) {
+	r := New[int, SizedString]()
+	r.Insert(0, 1, "abc")
+	r.Insert(1, 2, "") // markers stacked at 3
+	r.Insert(2, 3, "")
+	r.Insert(3, 4, "")
+	r.Insert(4, 5, "def")
+
+	type testCase struct {
+		position int
+		g        Gravity
+		id       int
+		offset   int
+	}
+	cases := []testCase{
+		{3, GravityLeft, 1, 0},
+		{3, GravityRight, 4, 0},
+		{3, GravityNone, 5, 3},
+		{0, GravityLeft, 0, 0},
+		{0, GravityRight, 0, 0},
+		{0, GravityNone, 1, 3},
+		{5, GravityLeft, 5, 1},
+		{5, GravityRight, 5, 1},
+		{5, GravityNone, 5, 1},
+		{6, GravityLeft, 5, 0},
+		{6, GravityRight, 5, 0},
+		{6, GravityNone, 5, 0},
+	}
+
+	for _, c := range cases {
+		id, offset := r.ByPositionG(c.position, c.g)
+		if id != c.id || offset != c.offset {
+			t.Errorf("position=%d gravity=%d: wanted=%d/%d, got=%d/%d", c.position, c.g, c.id, c.offset, id, offset)
+		}
+	}
+}
+
+func TestCaretAt(t *testing.T) {
+	r := New[int, SizedString]()
+	r.Insert(0, 1, "") // marker at 0
+	r.Insert(1, 2, "abc")
+	r.Insert(2, 3, "de")
+
+	if id, offset := r.CaretAt(0); id != 1 || offset != 0 {
+		t.Errorf("expected caret at zero after marker, got: %d/%d", id, offset)
+	}
+	if id, offset := r.CaretAt(2); id != 2 || offset != 1 {
+		t.Errorf("expected caret mid-node, got: %d/%d", id, offset)
+	}
+	if id, offset := r.CaretAt(100); id != 3 || offset != 0 {
+		t.Errorf("expected caret at end, got: %d/%d", id, offset)
+	}
+
+	for p := range r.Len() + 1 {
+		anchor, offset := r.CaretAt(p)
+		if offset != 0 {
+			if r.Find(anchor)-offset != p {
+				t.Errorf("mid-node caret for p=%d doesn't point at p", p)
+			}
+			continue
+		}
+
+		newId := nextId()
+		r.Insert(anchor, newId, "X")
+		if start := r.Find(newId) - 1; start != p {
+			t.Errorf("insert at caret for p=%d started at %d", p, start)
+		}
+		r.Delete(anchor, newId)
+	}
+}
+
+func TestByPositionTrailingZeroLength(t *testing.T) {
+	for _, trailing := range []int{2, 3} {
+		r := New[int, SizedString]()
+		r.Insert(0, 1, "abc")
+		for id := 2; id < 2+trailing; id++ {
+			r.Insert(id-1, id, "")
+		}
+		last := 1 + trailing
+
+		if id, offset := r.ByPosition(r.Len(), true); id != last || offset != 0 {
+			t.Errorf("trailing=%d: expected final id=%d, got: %d/%d", trailing, last, id, offset)
+		}
+		if id, offset := r.ByPosition(r.Len(), false); id != 1 || offset != 0 {
+			t.Errorf("trailing=%d: expected id=1 without biasAfter, got: %d/%d", trailing, id, offset)
+		}
+		if got := r.ByPositions([]int{r.Len()}, true); got[0].Id != last {
+			t.Errorf("trailing=%d: expected ByPositions to match, got: %+v", trailing, got)
+		}
+	}
+}
+
+func TestByPositions(t *testing.T) {
+	r, _ := BuildFromSlice(randomEntries(1000))
+
+	for _, count := range []int{5, 5000} {
+		positions := make([]int, count)
+		for i := range positions {
+			positions[i] = rand.IntN(r.Len()+4) - 2
+		}
+
+		for _, biasAfter := range []bool{false, true} {
+			got := r.ByPositions(positions, biasAfter)
+			for i, p := range positions {
+				id, offset := r.ByPosition(p, biasAfter)
+				if p < 0 || p > r.Len() {
+					id, offset = 0, 0
+				}
+				if got[i].Id != id || got[i].Offset != offset {
+					t.Fatalf("count=%d position=%d biasAfter=%v: wanted=%d/%d, got=%+v", count, p, biasAfter, id, offset, got[i])
+				}
+			}
+		}
+	}
+
+	if got := r.ByPositions(nil, false); len(got) != 0 {
+		t.Errorf("expected no results, got: %v", got)
+	}
+}
+
+func TestByMeasure(t *testing.T) {
+	// each entry is wider than its length, and zero-length entries still have width
+	length := func(s SizedString) int { return len(s) }
+	width := func(s SizedString) int { return 2*len(s) + 1 }
+	measureFns := []func(SizedString) int{length, width}
+	r := NewWithMeasures[int](SizedString(""), measureFns...)
+
+	ids := []int{0}
+	for range 500 {
+		if len(ids) > 2 && rand.IntN(4) == 0 {
+			choice := 1 + rand.IntN(len(ids)-1)
+			r.Delete(r.Info(ids[choice]).Prev, ids[choice])
+			ids = append(ids[:choice], ids[choice+1:]...)
+			continue
+		}
+		newId := nextId()
+		r.Insert(ids[rand.IntN(len(ids))], newId, SizedString("abcd"[:rand.IntN(5)]))
+		ids = append(ids, newId)
+	}
+	r.ReplaceKeepingId(ids[1], "longer", 6, nil)
+
+	if err := r.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+
+	type hit struct {
+		id, offset int
+	}
+	for index, fn := range measureFns {
+		var expected []hit // for every value up to the total measure
+		expected = append(expected, hit{0, 0})
+		var prefix int
+		for id, dl := range r.Iter(0) {
+			w := fn(dl.Data)
+			for offset := w - 1; offset >= 0; offset-- {
+				expected = append(expected, hit{id, offset})
+			}
+			prefix += w
+			if got := r.PrefixMeasure(id, index); got != prefix {
+				t.Fatalf("bad PrefixMeasure(%d, %d): wanted=%d, got=%d", id, index, prefix, got)
+			}
+		}
+
+		for value, want := range expected {
+			id, offset := r.ByMeasure(index, value)
+			if id != want.id || offset != want.offset {
+				t.Fatalf("bad ByMeasure(%d, %d): wanted=%+v, got=%d/%d", index, value, want, id, offset)
+			}
+		}
+		if id, offset := r.ByMeasure(index, len(expected)); id != r.LastId() || offset != 0 {
+			t.Errorf("expected lastId past the end, got: %d/%d", id, offset)
+		}
+	}
+
+	// the length measure matches the rope's own length
+	if got := r.PrefixMeasure(r.LastId(), 0); got != r.Len() {
+		t.Errorf("expected length measure=%d, got=%d", r.Len(), got)
+	}
+	if got := r.PrefixMeasure(-1, 0); got != -1 {
+		t.Errorf("expected -1 for missing id, got=%d", got)
+	}
+}
+
+func TestConcatMeasure(t *testing.T) {
+	width := func(s SizedString) int { return 2 * len(s) }
+	build := func(measured bool, id int) Rope[int, SizedString] {
+		r := New[int, SizedString]()
+		if measured {
+			r = NewWithMeasure[int](SizedString(""), width)
+		}
+		for i := range 100 {
+			r.Insert(0, id+i, "abcd")
+		}
+		return r
+	}
+
+	// a plain rope's entries are measured on joining a measured one
+	r := build(true, 1)
+	if err := r.Concat(build(false, 1000)); err != nil {
+		t.Fatalf("couldn't concat: %v", err)
+	} else if err := r.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+	if id, offset := r.ByMeasure(0, 8*100+3); id != 1099 || offset != 5 {
+		t.Errorf("bad ByMeasure after concat: %d/%d", id, offset)
+	}
+
+	// and a measured rope's entries are not on joining a plain one
+	p := build(false, 1)
+	if err := p.Concat(build(true, 1000)); err != nil {
+		t.Fatalf("couldn't concat: %v", err)
+	} else if err := p.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+	if id, offset := p.ByMeasure(0, 1); id != p.LastId() || offset != 0 {
+		t.Errorf("expected no measure after concat, got: %d/%d", id, offset)
+	}
+
+	// undoing a concat gives other back its own measures
+	r, other := build(true, 1), build(false, 1000)
+	r.Transaction(func(tx Rope[int, SizedString]) error {
+		tx.Concat(other)
+		return errAbort
+	})
+	for _, x := range []Rope[int, SizedString]{r, other} {
+		if err := x.Validate(); err != nil {
+			t.Fatalf("invalid after rollback: %v", err)
+		}
+	}
+	if r.Count() != 100 || other.Count() != 100 {
+		t.Errorf("expected counts restored, got: %d %d", r.Count(), other.Count())
+	}
+}
+
+func TestIsBoundary(t *testing.T) {
+	r := New[int, SizedString]()
+	if !r.IsBoundary(0) {
+		t.Errorf("expected zero to be a boundary of an empty rope")
+	}
+
+	r.Insert(0, 1, "abc")
+	r.Insert(1, 2, "")
+	r.Insert(2, 3, "de")
+
+	for pos, want := range []bool{true, false, false, true, false, true, false} {
+		if got := r.IsBoundary(pos); got != want {
+			t.Errorf("IsBoundary(%d) = %v, want %v", pos, got, want)
+		}
+	}
+	if r.IsBoundary(-1) {
+		t.Errorf("expected negative position not to be a boundary")
+	}
+}
+
+func TestPositionOf(t *testing.T) {
+	r, _ := BuildFromSlice(randomEntries(200))
+	r.Insert(0, -1, "") // zero-length entries are fine too
+
+	for _, biasAfter := range []bool{false, true} {
+		for p := 0; p <= r.Len(); p++ {
+			id, offset := r.ByPosition(p, biasAfter)
+			if got, ok := r.PositionOf(id, offset); !ok || got != p {
+				t.Fatalf("PositionOf(ByPosition(%d, %v)) = %d/%v", p, biasAfter, got, ok)
+			}
+		}
+	}
+
+	id, _ := r.ByPosition(1, false)
+	if _, ok := r.PositionOf(id, r.Info(id).Len+1); ok {
+		t.Errorf("expected offset past the start of the Id to fail")
+	}
+	if _, ok := r.PositionOf(id, -1); ok {
+		t.Errorf("expected negative offset to fail")
+	}
+	if _, ok := r.PositionOf(12345, 0); ok {
+		t.Errorf("expected missing Id to fail")
+	}
+}
+
+// weightedItem has a length from its text, but a separate weight.
+type weightedItem struct {
+	text   string
+	weight int
+}
+
+func (w weightedItem) Len() int { return len(w.text) }
+
+func TestMeasuresOptIn(t *testing.T) {
+	plain := New[int, SizedString]()
+	for i := range 100 {
+		plain.Insert(i, i+1, "ab")
+	}
+	if impl := plain.(*ropeImpl[int, SizedString]); impl.measured() || len(impl.nodeMeasures) != 0 {
+		t.Errorf("expected no measures without NewWithMeasures")
+	}
+
+	width := func(s SizedString) int { return 2 * len(s) }
+	build := func(from, to int) Rope[int, SizedString] {
+		r := NewWithMeasure[int](SizedString(""), width)
+		prev := 0
+		for id := from; id < to; id++ {
+			r.Insert(prev, id, SizedString("abc"[:id%4]))
+			prev = id
+		}
+		return r
+	}
+
+	r := build(1, 200)
+	other := build(200, 500)
+	if err := r.Concat(other); err != nil {
+		t.Fatalf("could not concat: %v", err)
+	} else if err := r.Validate(); err != nil {
+		t.Fatalf("invalid after concat: %v", err)
+	}
+	r.Rebalance()
+	if err := r.Validate(); err != nil {
+		t.Fatalf("invalid after rebalance: %v", err)
+	}
+
+	var want int
+	for id, dl := range r.Iter(0) {
+		want += width(dl.Data)
+		if got := r.PrefixMeasure(id, 0); got != want {
+			t.Fatalf("bad PrefixMeasure(%d): wanted=%d, got=%d", id, want, got)
+		}
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	r := NewWeighted[int](func(w weightedItem) int { return w.weight })
+	r.Insert(0, 1, weightedItem{"abc", 10})
+	r.Insert(1, 2, weightedItem{"", 5})
+	r.Insert(2, 3, weightedItem{"de", 0})
+	r.Insert(3, 4, weightedItem{"f", 1})
+
+	if r.Len() != 6 || r.TotalWeight() != 16 {
+		t.Errorf("expected len=6 weight=16, got len=%d weight=%d", r.Len(), r.TotalWeight())
+	}
+	for id, want := range []int{0, 10, 15, 15, 16} {
+		if got := r.PrefixWeight(id); got != want {
+			t.Errorf("PrefixWeight(%d) = %d, want %d", id, got, want)
+		}
+	}
+
+	type hit struct {
+		id, offset int
+	}
+	for w, want := range map[int]hit{
+		0:  {0, 0},
+		1:  {1, 9},
+		10: {1, 0},
+		11: {2, 4},
+		15: {2, 0},
+		16: {4, 0},
+	} {
+		if id, offset := r.ByWeight(w); id != want.id || offset != want.offset {
+			t.Errorf("ByWeight(%d) = %d/%d, want %+v", w, id, offset, want)
+		}
+	}
+
+	if got := New[int, SizedString]().TotalWeight(); got != 0 {
+		t.Errorf("expected no weight without NewWeighted, got: %d", got)
+	}
+}
@@ -0,0 +1,108 @@
+package rope
+
+import (
+	"math/bits"
+)
+
+func (r *ropeImpl[Id, T]) RankLogN(id Id) int {
+	e := r.byId[id]
+	if e == nil {
+		return -1
+	} else if e == &r.head {
+		return 0
+	}
+
+	node := e
+	var rank int
+
+	for node != &r.head {
+		link := len(node.levels) - 1
+		node = node.levels[link].prev
+		rank += node.levels[link].count
+	}
+
+	return rank + 1
+}
+
+func (r *ropeImpl[Id, T]) CountBetween(afterA, afterB Id) (count int, ok bool) {
+	rankA := r.RankLogN(afterA)
+	if rankA < 0 {
+		return
+	}
+
+	rankB := r.RankLogN(afterB)
+	if rankB < 0 {
+		return
+	}
+
+	switch {
+	case rankA < rankB:
+		return rankB - rankA - 1, true
+	case rankA > rankB:
+		return -(rankA - rankB - 1), true
+	}
+	return 0, true
+}
+
+func (r *ropeImpl[Id, T]) SelectLogN(n int) (id Id, ok bool) {
+	if n < 0 || n > r.Count() {
+		return
+	}
+
+	// this is ByPosition where every node has length one
+	e := &r.head
+	for h := r.height - 1; h >= 0; h-- {
+		for n > e.levels[h].count {
+			n -= e.levels[h].count
+			e = e.levels[h].next
+		}
+	}
+
+	return e.id, true
+}
+
+func (r *ropeImpl[Id, T]) PositionMap() map[Id]int {
+	out := make(map[Id]int, len(r.byId))
+	out[r.head.id] = 0
+
+	var pos int
+	for node := r.head.levels[0].next; node != nil; node = node.levels[0].next {
+		pos += node.dl.Len
+		out[node.id] = pos
+	}
+	return out
+}
+
+func (r *ropeImpl[Id, T]) Finds(ids []Id) map[Id]int {
+	out := make(map[Id]int, len(ids))
+	for _, id := range ids {
+		if _, ok := r.byId[id]; ok {
+			out[id] = -1 // found, but not yet walked to
+		}
+	}
+	if _, ok := out[r.head.id]; ok {
+		out[r.head.id] = 0
+	}
+
+	// for only a few ids, it's cheaper to descend for each
+	if len(out)*bits.Len(uint(r.Count())) < r.Count() {
+		for id := range out {
+			out[id] = r.Find(id)
+		}
+		return out
+	}
+
+	remaining := len(out)
+	if _, ok := out[r.head.id]; ok {
+		remaining--
+	}
+	var pos int
+	for node := r.head.levels[0].next; node != nil && remaining != 0; node = node.levels[0].next {
+		pos += node.dl.Len
+		if _, ok := out[node.id]; ok {
+			out[node.id] = pos
+			remaining--
+		}
+	}
+	return out
+}
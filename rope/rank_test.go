@@ -0,0 +1,184 @@
+package rope
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestRankSelect(t *testing.T) {
+	r := New[int, SizedString]()
+	ids := []int{0}
+
+	for i := range 2000 {
+		if len(ids) <= 2 || rand.IntN(4) != 0 {
+			newId := nextId()
+			r.Insert(ids[rand.IntN(len(ids))], newId, SizedString("abc"[:rand.IntN(3)]))
+			ids = append(ids, newId)
+		} else {
+			choice := 1 + rand.IntN(len(ids)-1)
+			deleteId := ids[choice]
+			ids[choice] = ids[len(ids)-1]
+			ids = ids[:len(ids)-1]
+			r.Delete(r.Info(deleteId).Prev, deleteId)
+		}
+
+		if i%100 != 0 {
+			continue
+		}
+		if err := r.Validate(); err != nil {
+			t.Fatalf("invalid after op=%d: %v", i, err)
+		}
+
+		rank := 0
+		for id := range r.Iter(0) {
+			rank++
+			if got := r.RankLogN(id); got != rank {
+				t.Fatalf("bad rank for id=%d: wanted=%d, got=%d", id, rank, got)
+			}
+			if got, ok := r.SelectLogN(rank); !ok || got != id {
+				t.Fatalf("bad select for rank=%d: wanted=%d, got=%d", rank, id, got)
+			}
+		}
+	}
+
+	if r.RankLogN(0) != 0 || r.RankLogN(-1) != -1 {
+		t.Errorf("bad rank for zero or missing")
+	}
+	if id, ok := r.SelectLogN(0); !ok || id != 0 {
+		t.Errorf("bad select for zero")
+	}
+	if _, ok := r.SelectLogN(r.Count() + 1); ok {
+		t.Errorf("expected select past end to fail")
+	}
+}
+
+func TestCountBetween(t *testing.T) {
+	r := New[int, SizedString]()
+	for i, s := range []SizedString{"abc", "", "de", "f"} {
+		r.Insert(i, i+1, s)
+	}
+
+	tests := []struct {
+		a, b, want int
+	}{
+		{0, 4, 3},
+		{1, 4, 2}, // the zero-length entry counts
+		{4, 1, -2},
+		{1, 2, 0}, // adjacent
+		{2, 1, 0},
+		{2, 2, 0},
+		{0, 0, 0},
+	}
+	for _, tt := range tests {
+		if got, ok := r.CountBetween(tt.a, tt.b); !ok || got != tt.want {
+			t.Errorf("CountBetween(%d, %d) = %d/%v, want %d", tt.a, tt.b, got, ok, tt.want)
+		}
+	}
+	if _, ok := r.CountBetween(1, 99); ok {
+		t.Errorf("expected missing Id to fail")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	r, _ := BuildFromSlice(randomEntries(100))
+	if err := r.Validate(); err != nil {
+		t.Fatalf("expected valid: %v", err)
+	}
+
+	impl := r.(*ropeImpl[int, SizedString])
+	impl.head.levels[0].next.levels[0].count++
+	if err := r.Validate(); err == nil {
+		t.Errorf("expected bad count to be found")
+	}
+}
+
+func TestReachable(t *testing.T) {
+	r := buildIdRope(10)
+	for id := range 11 {
+		if !r.Reachable(id) {
+			t.Errorf("expected id=%d to be reachable", id)
+		}
+	}
+	if r.Reachable(99) {
+		t.Errorf("expected missing id not to be reachable")
+	}
+
+	// orphan an entry, which is still indexed but skipped by its neighbors
+	node := r.(*ropeImpl[int, SizedString]).byId[5]
+	node.levels[0].prev.levels[0].next = node.levels[0].next
+	if r.Reachable(5) || r.Info(5).Id != 5 {
+		t.Errorf("expected orphaned id to be indexed but not reachable")
+	}
+	if !r.Reachable(6) {
+		t.Errorf("expected entries after the orphan to be reachable")
+	}
+}
+
+func TestPositionMap(t *testing.T) {
+	r, _ := BuildFromSlice(randomEntries(1000))
+	r.Delete(0, r.(*ropeImpl[int, SizedString]).head.levels[0].next.id)
+
+	m := r.PositionMap()
+	if len(m) != r.Count()+1 {
+		t.Fatalf("expected every id and zero, got: %d", len(m))
+	}
+	for id, pos := range m {
+		if at := r.Find(id); at != pos {
+			t.Errorf("bad position for id=%d: wanted=%d, got=%d", id, at, pos)
+		}
+	}
+	if m[0] != 0 {
+		t.Errorf("expected zero Id at zero")
+	}
+}
+
+func TestFinds(t *testing.T) {
+	r, _ := BuildFromSlice(randomEntries(1000))
+	var all []int
+	for id := range r.Iter(0) {
+		all = append(all, id)
+	}
+
+	// a few ids descend for each, and many walk once
+	for _, k := range []int{5, 800} {
+		ids := []int{0, -12345}
+		for range k {
+			ids = append(ids, all[rand.IntN(len(all))])
+		}
+
+		got := r.Finds(ids)
+		for _, id := range ids {
+			pos, ok := got[id]
+			if want := r.Find(id); want == -1 {
+				if ok {
+					t.Errorf("expected missing id=%d to be omitted", id)
+				}
+			} else if pos != want {
+				t.Errorf("k=%d: bad Finds for id=%d: wanted=%d, got=%d", k, id, want, pos)
+			}
+		}
+	}
+}
+
+func benchmarkFinds(b *testing.B, each bool) {
+	r, _ := BuildFromSlice(randomEntries(100_000))
+	ids := make([]int, 0, r.Count()/2)
+	for id := range r.Iter(0) {
+		if rand.IntN(2) == 0 {
+			ids = append(ids, id)
+		}
+	}
+
+	for b.Loop() {
+		if each {
+			for _, id := range ids {
+				r.Find(id)
+			}
+		} else {
+			r.Finds(ids)
+		}
+	}
+}
+
+func BenchmarkFinds(b *testing.B)     { benchmarkFinds(b, false) }
+func BenchmarkFindsEach(b *testing.B) { benchmarkFinds(b, true) }
@@ -0,0 +1,235 @@
+package rope
+
+func (r *ropeImpl[Id, T]) ReplaceByPosition(startPos, endPos int, newId Id, data T, newLen int) ([]Removed[Id, T], error) {
+	if startPos < 0 || startPos > endPos || endPos > r.len {
+		return nil, ErrBadRange
+	} else if _, exists := r.byId[newId]; exists {
+		return nil, ErrIdExists
+	} else if err := r.checkLen(data, newLen); err != nil {
+		return nil, err
+	}
+
+	// first is the entry containing startPos, or the last entry ending at it
+	firstId, firstOffset := r.ByPosition(startPos, true)
+	first := r.byId[firstId]
+	if firstOffset != 0 && firstOffset == first.dl.Len {
+		// we're at the start of this entry, so the anchor is the one before
+		first = first.levels[0].prev
+		firstOffset = 0
+	}
+
+	var last *ropeNode[Id, T]
+	var lastOffset int
+	if startPos == endPos {
+		if firstOffset != 0 {
+			return nil, ErrWithinNode
+		}
+	} else {
+		lastId, offset := r.ByPosition(endPos, false)
+		last, lastOffset = r.byId[lastId], offset
+
+		if first == last && lastOffset != 0 {
+			return nil, ErrWithinNode
+		}
+	}
+
+	// check both ends can be trimmed before changing anything
+	if firstOffset != 0 {
+		if _, ok := any(first.dl.Data).(Slicer[T]); !ok {
+			return nil, ErrNotSlicer
+		}
+	}
+	if lastOffset != 0 {
+		if _, ok := any(last.dl.Data).(Slicer[T]); !ok {
+			return nil, ErrNotSlicer
+		}
+	}
+
+	if firstOffset != 0 {
+		keep := first.dl.Len - firstOffset
+		r.setNode(first, any(first.dl.Data).(Slicer[T]).Slice(0, keep), keep)
+	}
+
+	// remove everything after first until last, keeping last if it's only partially covered
+	doDelete := last != nil && last != first
+	var deleteUntil Id
+	if doDelete {
+		if lastOffset == 0 {
+			deleteUntil = last.id
+		} else {
+			prev := last.levels[0].prev
+			doDelete = prev != first
+			deleteUntil = prev.id
+		}
+	}
+
+	var removed []Removed[Id, T]
+	err := r.splice(first, doDelete, deleteUntil, true, newId, newLen, data, func(rm Removed[Id, T]) {
+		removed = append(removed, rm)
+	})
+	if err != nil {
+		return removed, err
+	}
+
+	if lastOffset != 0 {
+		r.setNode(last, any(last.dl.Data).(Slicer[T]).Slice(last.dl.Len-lastOffset, last.dl.Len), lastOffset)
+	}
+
+	return removed, nil
+}
+
+func (r *ropeImpl[Id, T]) ReplaceData(match func(DataLen[T]) bool, replace func(T) (T, int)) (changed int, err error) {
+	// Track the last node seen at every level, and the total change in length (and measure) when it was seen.
+	// Each level's size is only updated once we move past that node, rather than seeking for every change.
+	var tailsStack [maxHeight]ropeSeek[Id, T]
+	tails, pooled := r.getSeek(&tailsStack, r.height)
+	defer r.putSeek(pooled)
+	for i := range tails {
+		tails[i].node = &r.head
+	}
+	var tailMeasures []measures // alongside tails, the change in measure when it was seen
+	if r.measured() {
+		tailMeasures = make([]measures, len(tails))
+	}
+
+	r.recordMarkers()
+
+	var delta, start int // start is the position of node, including changes so far
+	var mdelta measures
+	for node := r.head.levels[0].next; node != nil; start, node = start+node.dl.Len, node.levels[0].next {
+		for i := range node.levels {
+			tails[i].node.levels[i].subtreesize += delta - tails[i].sub
+			if tailMeasures != nil {
+				tm := r.levelMeasures(tails[i].node)
+				tm[i] = tm[i].add(mdelta).sub(tailMeasures[i])
+				tailMeasures[i] = mdelta
+			}
+			tails[i] = ropeSeek[Id, T]{node: node, sub: delta}
+		}
+		if !match(node.dl) {
+			continue
+		}
+
+		data, length := replace(node.dl.Data)
+		if err = r.checkLen(data, length); err != nil {
+			break
+		}
+		if r.txDepth != 0 {
+			id, old := node.id, node.dl
+			r.record(func() { r.setNode(r.byId[id], old.Data, old.Len) })
+		}
+		if r.hasMarkers() {
+			r.shiftMarkers(start+min(node.dl.Len, length), length-node.dl.Len)
+		}
+		delta += length - node.dl.Len
+		r.contentCount += hasLen(length) - hasLen(node.dl.Len)
+		if tailMeasures != nil {
+			mdelta = mdelta.add(r.measureOf(data)).sub(r.levelMeasure(node, 0))
+		}
+		node.dl = DataLen[T]{Len: length, Data: data}
+		changed++
+	}
+
+	for i := range tails {
+		tails[i].node.levels[i].subtreesize += delta - tails[i].sub
+		if tailMeasures != nil {
+			tm := r.levelMeasures(tails[i].node)
+			tm[i] = tm[i].add(mdelta).sub(tailMeasures[i])
+		}
+	}
+	r.len += delta
+	r.measure = r.measure.add(mdelta)
+	if changed != 0 {
+		r.version++
+	}
+	return changed, err
+}
+
+func (r *ropeImpl[Id, T]) ReplaceKeepingId(id Id, data T, newLen int, remapOffset func(old int) int) error {
+	node := r.byId[id]
+	if node == nil || node == &r.head {
+		return ErrBadAnchor
+	} else if err := r.checkLen(data, newLen); err != nil {
+		return err
+	}
+
+	if remapOffset == nil || !r.hasMarkers() {
+		r.setNode(node, data, newLen)
+		return nil
+	}
+
+	end := r.Find(id)
+	start, delta := end-node.dl.Len, newLen-node.dl.Len
+	move := func(pos int) int {
+		if pos <= start {
+			return pos
+		} else if pos > end {
+			return pos + delta
+		}
+		// offsets count back from the end
+		return end + delta - max(0, min(remapOffset(end-pos), newLen))
+	}
+
+	// hide markers while changing the node, so they are moved here instead
+	r.recordMarkers()
+	markers, annotations := r.markers, r.annotations
+	r.markers, r.annotations = nil, nil
+	r.setNode(node, data, newLen)
+	r.markers, r.annotations = markers, annotations
+
+	for _, m := range r.markers {
+		m.pos = move(m.pos)
+	}
+	if len(r.annotations) != 0 {
+		r.moveAnnotations(move)
+	}
+	return nil
+}
+
+// setNode changes the data and length of a node.
+func (r *ropeImpl[Id, T]) setNode(node *ropeNode[Id, T], data T, length int) {
+	id, old := node.id, node.dl
+	if r.txDepth != 0 {
+		r.recordMarkers()
+		r.record(func() { r.setNode(r.byId[id], old.Data, old.Len) })
+	}
+
+	node.dl.Data = data
+	r.setLen(node, length)
+}
+
+// setLen changes the length of a node, and updates its measure from its data, updating all levels which include it.
+// Costs ~O(logn).
+func (r *ropeImpl[Id, T]) setLen(node *ropeNode[Id, T], length int) {
+	delta := length - node.dl.Len
+	var mdelta measures
+	if r.measured() {
+		mdelta = r.measureOf(node.dl.Data).sub(r.levelMeasure(node, 0))
+	}
+	if delta == 0 && mdelta == (measures{}) {
+		return
+	}
+
+	if r.hasMarkers() {
+		start := r.Find(node.id) - node.dl.Len
+		r.shiftMarkers(start+min(node.dl.Len, length), delta)
+	}
+
+	var pathStack [maxHeight]*ropeNode[Id, T]
+	path, pooled := r.getNodes(&pathStack, r.height)
+	defer r.putNodes(pooled)
+	r.rseekNodes(node, path)
+	for i := range r.height {
+		path[i].levels[i].subtreesize += delta
+		if r.measured() {
+			pm := r.levelMeasures(path[i])
+			pm[i] = pm[i].add(mdelta)
+		}
+	}
+
+	r.contentCount += hasLen(length) - hasLen(node.dl.Len)
+	node.dl.Len = length
+	r.len += delta
+	r.measure = r.measure.add(mdelta)
+	r.version++
+}
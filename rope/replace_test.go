@@ -0,0 +1,216 @@
+package rope
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func (s SizedString) Slice(start, end int) SizedString { return s[start:end] }
+
+func materialize(r Rope[int, SizedString]) (out string) {
+	for _, dl := range r.Iter(0) {
+		out += string(dl.Data)
+	}
+	return out
+}
+
+func TestReplaceByPosition(t *testing.T) {
+	r := New[int, SizedString]()
+	r.Insert(0, 1, "hello")
+	r.Insert(1, 2, " there ")
+	r.Insert(2, 3, "bob")
+
+	// covers "lo there b"
+	removed, err := r.ReplaceByPosition(3, 13, 4, "p, ", 3)
+	if err != nil {
+		t.Fatalf("couldn't replace: %v", err)
+	}
+	if len(removed) != 1 || removed[0].Id != 2 || removed[0].Data != " there " {
+		t.Errorf("expected middle to be removed, got: %+v", removed)
+	}
+	if s := materialize(r); s != "help, ob" {
+		t.Errorf("bad content: %q", s)
+	}
+	if r.Len() != 8 || r.Count() != 3 {
+		t.Errorf("bad len=%d count=%d", r.Len(), r.Count())
+	}
+
+	// boundary nodes keep their Ids
+	if r.Find(1) != 3 || r.Find(4) != 6 || r.Find(3) != 8 {
+		t.Errorf("bad positions: %d %d %d", r.Find(1), r.Find(4), r.Find(3))
+	}
+	if info := r.Info(3); info.Prev != 4 || info.Len != 2 || info.Data != "ob" {
+		t.Errorf("bad trimmed last: %+v", info)
+	}
+
+	if _, err := r.ReplaceByPosition(1, 2, 5, "x", 1); err != ErrWithinNode {
+		t.Errorf("expected ErrWithinNode, got: %v", err)
+	}
+	if _, err := r.ReplaceByPosition(2, 100, 5, "x", 1); err != ErrBadRange {
+		t.Errorf("expected ErrBadRange, got: %v", err)
+	}
+
+	// replace exactly aligned with entries removes them whole
+	removed, err = r.ReplaceByPosition(3, 6, 5, "!", 1)
+	if err != nil || len(removed) != 1 || removed[0].Id != 4 {
+		t.Errorf("bad aligned replace: %+v %v", removed, err)
+	}
+	if s := materialize(r); s != "hel!ob" {
+		t.Errorf("bad content: %q", s)
+	}
+
+	// empty range just inserts
+	if _, err := r.ReplaceByPosition(6, 6, 6, "?", 1); err != nil {
+		t.Errorf("couldn't insert at end: %v", err)
+	}
+	if s := materialize(r); s != "hel!ob?" || r.LastId() != 6 {
+		t.Errorf("bad content: %q lastId=%d", s, r.LastId())
+	}
+	if err := r.Validate(); err != nil {
+		t.Errorf("invalid after replace: %v", err)
+	}
+}
+
+func TestReplaceByPositionNotSlicer(t *testing.T) {
+	r := New[int, SizedEmpty]()
+	r.Insert(0, 1, 5)
+	r.Insert(1, 2, 5)
+
+	if _, err := r.ReplaceByPosition(2, 7, 3, 1, 1); err != ErrNotSlicer {
+		t.Errorf("expected ErrNotSlicer, got: %v", err)
+	}
+	if r.Len() != 10 || r.Count() != 2 {
+		t.Errorf("rope should not change")
+	}
+
+	if _, err := r.ReplaceByPosition(0, 10, 3, 1, 1); err != nil {
+		t.Errorf("aligned replace needs no Slicer: %v", err)
+	}
+	if r.Len() != 1 || r.Count() != 1 || r.LastId() != 3 {
+		t.Errorf("bad replace: len=%d count=%d", r.Len(), r.Count())
+	}
+}
+
+func TestReplaceByPositionKeepsMarkers(t *testing.T) {
+	r := New[int, SizedString]()
+	r.Insert(0, 1, "abc")
+	r.Insert(1, 2, "") // marker at 3
+	r.Insert(2, 3, "def")
+	r.Insert(3, 4, "") // marker at 6
+	r.Insert(4, 5, "ghi")
+
+	removed, err := r.ReplaceByPosition(3, 6, 6, "X", 1)
+	if err != nil || len(removed) != 1 || removed[0].Id != 3 {
+		t.Fatalf("bad replace: %+v %v", removed, err)
+	}
+	if r.Info(6).Prev != 2 || r.Info(6).Next != 4 {
+		t.Errorf("markers should surround new entry: %+v", r.Info(6))
+	}
+	if s := materialize(r); s != "abcXghi" {
+		t.Errorf("bad content: %q", s)
+	}
+}
+
+func TestReplaceData(t *testing.T) {
+	words := []string{"the", "cat", "sat", "on", "the", "mat", "with", "a", "hat", "", "that"}
+	r := New[int, SizedString]()
+	for i, w := range words {
+		r.Insert(i, i+1, SizedString(w))
+	}
+
+	match := func(dl DataLen[SizedString]) bool {
+		return strings.Contains(string(dl.Data), "at")
+	}
+	changed, err := r.ReplaceData(match, func(s SizedString) (SizedString, int) {
+		out := strings.ReplaceAll(string(s), "at", "oat")
+		return SizedString(out), len(out)
+	})
+	if err != nil || changed != 5 {
+		t.Fatalf("expected 5 changes, got: %d %v", changed, err)
+	}
+
+	var entries []Info[int, SizedString]
+	var want string
+	for i, w := range words {
+		w = strings.ReplaceAll(w, "at", "oat")
+		want += w
+		entries = append(entries, Info[int, SizedString]{Id: i + 1, DataLen: DataLen[SizedString]{Len: len(w), Data: SizedString(w)}})
+	}
+	checkEntries(t, r, entries)
+	if got := materialize(r); got != want {
+		t.Errorf("bad content: wanted=%q, got=%q", want, got)
+	}
+
+	// stops at a negative length, keeping earlier changes
+	changed, err = r.ReplaceData(match, func(s SizedString) (SizedString, int) {
+		if s == "moat" {
+			return s, -1
+		}
+		return "", 0
+	})
+	if err != ErrNegativeLength || changed != 2 {
+		t.Errorf("expected ErrNegativeLength after 2 changes, got: %d %v", changed, err)
+	}
+	if err := r.Validate(); err != nil {
+		t.Errorf("invalid after failed replace: %v", err)
+	}
+
+	// rolls back within a transaction
+	before := collect(r)
+	r.Transaction(func(tx Rope[int, SizedString]) error {
+		tx.ReplaceData(func(DataLen[SizedString]) bool { return true }, func(SizedString) (SizedString, int) {
+			return "xyz", 3
+		})
+		return errAbort
+	})
+	if after := collect(r); !reflect.DeepEqual(before, after) {
+		t.Errorf("rope changed after rollback: %+v vs %+v", before, after)
+	}
+}
+
+func TestReplaceKeepingId(t *testing.T) {
+	r := New[int, SizedString]()
+	r.Insert(0, 1, "hello")
+	r.Insert(1, 2, " there")
+
+	// a cursor held outside the rope, two into " there", which is four back from its end
+	type cursor struct {
+		id, offset int
+	}
+	c := cursor{id: 2, offset: 4}
+	if id, offset := r.ByPosition(7, false); id != c.id || offset != c.offset {
+		t.Fatalf("bad cursor: %d/%d", id, offset)
+	}
+
+	clamp := func(old int) int { return min(old, 2) }
+	if err := r.ReplaceKeepingId(2, "!!", 2, clamp); err != nil {
+		t.Fatalf("couldn't replace: %v", err)
+	}
+	c.offset = clamp(c.offset)
+	if c.offset != 2 || r.Find(c.id)-c.offset != 5 {
+		t.Errorf("expected cursor clamped to start of shrunk entry, got: %+v at=%d", c, r.Find(c.id)-c.offset)
+	}
+	if got := materialize(r); got != "hello!!" {
+		t.Errorf("bad content: %q", got)
+	}
+
+	// offsets from the end are kept when it grows
+	c = cursor{id: 1, offset: 3}
+	if err := r.ReplaceKeepingId(1, "hello, world", 12, func(old int) int { return old }); err != nil {
+		t.Fatalf("couldn't replace: %v", err)
+	}
+	if r.Find(2) != 14 || r.Find(c.id)-c.offset != 9 {
+		t.Errorf("expected offset kept after grow")
+	}
+
+	if err := r.ReplaceKeepingId(0, "", 0, nil); err != ErrBadAnchor {
+		t.Errorf("expected ErrBadAnchor for zero Id, got: %v", err)
+	}
+	if err := r.ReplaceKeepingId(3, "", 0, nil); err != ErrBadAnchor {
+		t.Errorf("expected ErrBadAnchor for missing Id, got: %v", err)
+	}
+	if err := r.ReplaceKeepingId(1, "", -1, nil); err != ErrNegativeLength {
+		t.Errorf("expected ErrNegativeLength, got: %v", err)
+	}
+}
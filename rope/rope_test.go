@@ -1,6 +1,7 @@
 package rope
 
 import (
+	"errors"
 	"iter"
 	"math/rand/v2"
 	"reflect"
@@ -103,6 +104,48 @@ func BenchmarkCompare(b *testing.B) {
 	}
 }
 
+func BenchmarkCompareAdjacent(b *testing.B) {
+	r, _ := BuildFromSlice(randomEntries(100_000))
+	ids := make([]int, 0, r.Count())
+	for id := range r.Iter(0) {
+		ids = append(ids, id)
+	}
+
+	for b.Loop() {
+		i := rand.IntN(len(ids) - 1)
+		r.Compare(ids[i], ids[i+1])
+	}
+}
+
+func BenchmarkCompareDistant(b *testing.B) {
+	r, _ := BuildFromSlice(randomEntries(100_000))
+	ids := make([]int, 0, r.Count())
+	for id := range r.Iter(0) {
+		ids = append(ids, id)
+	}
+
+	for b.Loop() {
+		i := rand.IntN(len(ids) / 2)
+		r.Compare(ids[i], ids[i+len(ids)/2])
+	}
+}
+
+func BenchmarkCompareSmall(b *testing.B) {
+	// small enough to stay in cache, so the cost of Compare itself dominates
+	r := buildIdRope(1000)
+	pairs := make([][2]int, 1024)
+	for i := range pairs {
+		pairs[i] = [2]int{1 + rand.IntN(1000), 1 + rand.IntN(1000)}
+	}
+
+	i := 0
+	for b.Loop() {
+		p := pairs[i%len(pairs)]
+		r.Compare(p[0], p[1])
+		i++
+	}
+}
+
 func TestRope(t *testing.T) {
 	for i := 0; i < 50; i++ {
 		if t.Failed() {
@@ -140,9 +183,9 @@ func TestRope(t *testing.T) {
 
 		thereLookup := r.Info(thereId)
 		if !reflect.DeepEqual(thereLookup, Info[int, SizedString]{
-			Id:   thereId,
-			Next: 0,
-			Prev: helloId,
+			Id:      thereId,
+			Next:    0,
+			Prev:    helloId,
 			DataLen: DataLen[SizedString]{Data: " there", Len: 6},
 		}) {
 			t.Errorf("bad lookup=%+v", thereLookup)
@@ -240,3 +283,1261 @@ func TestIter(t *testing.T) {
 		t.Errorf("should not get more values: last deleted")
 	}
 }
+
+func TestSentinel(t *testing.T) {
+	const sentinel = -1
+	r := NewWithSentinel[int, SizedString](sentinel, "")
+
+	if r.LastId() != sentinel {
+		t.Errorf("expected empty rope to have lastId=sentinel, got: %d", r.LastId())
+	}
+
+	// zero is now a valid Id
+	if err := r.Insert(sentinel, 0, "hello"); err != nil {
+		t.Fatalf("couldn't insert zero: %v", err)
+	}
+	if err := r.Insert(0, 1, " there"); err != nil {
+		t.Fatalf("couldn't insert after zero: %v", err)
+	}
+	if err := r.Insert(0, sentinel, "nope"); err != ErrIdExists {
+		t.Errorf("expected ErrIdExists inserting sentinel, got: %v", err)
+	}
+
+	if r.Count() != 2 || r.Len() != 11 {
+		t.Errorf("bad count=%d len=%d", r.Count(), r.Len())
+	}
+	if r.Find(0) != 5 || r.Find(1) != 11 || r.Find(sentinel) != 0 {
+		t.Errorf("bad find: zero=%d one=%d", r.Find(0), r.Find(1))
+	}
+	if info := r.Info(0); info.Prev != sentinel || info.Next != 1 {
+		t.Errorf("bad info for zero: %+v", info)
+	}
+	if info := r.Info(1); info.Next != sentinel {
+		t.Errorf("expected next of last to be sentinel: %+v", info)
+	}
+	if id, _ := r.ByPosition(0, false); id != sentinel {
+		t.Errorf("expected position zero to be sentinel, got: %d", id)
+	}
+	if id, offset := r.ByPosition(3, false); id != 0 || offset != 2 {
+		t.Errorf("bad byPosition: id=%d offset=%d", id, offset)
+	}
+
+	removed, err := r.Delete(sentinel, 0)
+	if err != nil || len(removed) != 1 || removed[0].Id != 0 {
+		t.Errorf("bad delete of zero: %+v %v", removed, err)
+	}
+	if r.Find(1) != 6 || r.LastId() != 1 {
+		t.Errorf("bad state after delete: find=%d lastId=%d", r.Find(1), r.LastId())
+	}
+}
+
+func TestCompareTotalOrder(t *testing.T) {
+	r := New[int, SizedString]()
+	ids := []int{0}
+
+	for range 500 {
+		after := ids[rand.IntN(len(ids))]
+		newId := nextId()
+		r.Insert(after, newId, SizedString("abc"[:rand.IntN(3)])) // lots of zero-length
+		ids = append(ids, newId)
+	}
+
+	index := map[int]int{0: 0}
+	for id := range r.Iter(0) {
+		index[id] = len(index)
+	}
+
+	sign := func(v int) int {
+		return min(max(v, -1), 1)
+	}
+
+	for range 10_000 {
+		a := ids[rand.IntN(len(ids))]
+		b := ids[rand.IntN(len(ids))]
+		c := ids[rand.IntN(len(ids))]
+
+		ab, ok := r.Compare(a, b)
+		if !ok {
+			t.Fatalf("couldn't compare a=%d b=%d", a, b)
+		}
+		ba, _ := r.Compare(b, a)
+		if want := sign(index[a] - index[b]); ab != want || ba != -want {
+			t.Fatalf("bad compare a=%d b=%d: ab=%d ba=%d want=%d", a, b, ab, ba, want)
+		}
+
+		bc, _ := r.Compare(b, c)
+		ac, _ := r.Compare(a, c)
+		if ab < 0 && bc < 0 && ac >= 0 {
+			t.Fatalf("not transitive: a=%d b=%d c=%d", a, b, c)
+		}
+	}
+}
+
+func TestDeleteCount(t *testing.T) {
+	r := buildIdRope(10)
+
+	count, err := r.DeleteCount(2, 6)
+	if err != nil || count != 4 {
+		t.Errorf("expected to delete 4, got: %d %v", count, err)
+	}
+	if r.Count() != 6 || r.Len() != 6 || r.Info(2).Next != 7 {
+		t.Errorf("bad state after delete: count=%d len=%d", r.Count(), r.Len())
+	}
+
+	if count, _ := r.DeleteCount(2, 2); count != 0 {
+		t.Errorf("should delete nothing with same ids, got: %d", count)
+	}
+	if _, err := r.DeleteCount(100, 2); err != ErrBadAnchor {
+		t.Errorf("expected ErrBadAnchor, got: %v", err)
+	}
+
+	// deleting many entries allocates nothing, as nothing is returned
+	const runs = 20
+	entries := randomEntries(1_000)
+	lastId := entries[len(entries)-1].Id
+	ropes := make([]Rope[int, SizedString], runs+1) // AllocsPerRun also runs once to warm up
+	for i := range ropes {
+		ropes[i], _ = BuildFromSlice(entries)
+	}
+	allocs := testing.AllocsPerRun(runs, func() {
+		ropes[0].DeleteCount(0, lastId)
+		ropes = ropes[1:]
+	})
+	if allocs != 0 {
+		t.Errorf("expected DeleteCount not to allocate, got: %v", allocs)
+	}
+}
+
+func benchmarkLargeDelete(b *testing.B, del func(r Rope[int, SizedString], lastId int)) {
+	entries := randomEntries(10_000)
+	lastId := entries[len(entries)-1].Id
+	b.ReportAllocs()
+
+	for b.Loop() {
+		b.StopTimer()
+		r, _ := BuildFromSlice(entries)
+		b.StartTimer()
+
+		del(r, lastId)
+	}
+}
+
+func BenchmarkDelete(b *testing.B) {
+	benchmarkLargeDelete(b, func(r Rope[int, SizedString], lastId int) {
+		r.Delete(0, lastId)
+	})
+}
+
+func BenchmarkDeleteCount(b *testing.B) {
+	benchmarkLargeDelete(b, func(r Rope[int, SizedString], lastId int) {
+		r.DeleteCount(0, lastId)
+	})
+}
+
+func TestLastSpliceStats(t *testing.T) {
+	r := New[int, SizedString]()
+	r.Insert(0, 1, "hello")
+	r.Insert(1, 2, " there")
+	r.Insert(2, 3, " bob")
+
+	if rl, rc, il := r.LastSpliceStats(); rl != 0 || rc != 0 || il != 4 {
+		t.Errorf("bad stats after insert: %d %d %d", rl, rc, il)
+	}
+
+	until := 2
+	insert := 4
+	r.Splice(0, &until, &insert, "hi")
+	if rl, rc, il := r.LastSpliceStats(); rl != 11 || rc != 2 || il != 2 {
+		t.Errorf("bad stats after replace: %d %d %d", rl, rc, il)
+	}
+
+	r.DeleteCount(4, 3)
+	if rl, rc, il := r.LastSpliceStats(); rl != 4 || rc != 1 || il != 0 {
+		t.Errorf("bad stats after delete: %d %d %d", rl, rc, il)
+	}
+}
+
+func TestSpliceUntilNotAfter(t *testing.T) {
+	r := buildIdRope(5)
+
+	removed, err := r.Delete(3, 1)
+	if err != ErrUntilNotAfter || len(removed) != 0 {
+		t.Errorf("expected ErrUntilNotAfter, got: %v %v", removed, err)
+	}
+	if _, err := r.DeleteCount(3, 100); err != ErrUntilNotAfter {
+		t.Errorf("expected ErrUntilNotAfter for missing until, got: %v", err)
+	}
+
+	if r.Count() != 5 || r.Len() != 5 || r.LastId() != 5 {
+		t.Errorf("rope should not change: count=%d len=%d", r.Count(), r.Len())
+	}
+}
+
+func TestInsertInfo(t *testing.T) {
+	r := buildIdRope(3)
+
+	for _, after := range []int{0, 2, 3} {
+		newId := nextId()
+		info, err := r.InsertInfo(after, newId, "hello", 5)
+		if err != nil {
+			t.Fatalf("couldn't insert: %v", err)
+		}
+		if lookup := r.Info(newId); !reflect.DeepEqual(info, lookup) {
+			t.Errorf("returned info didn't match lookup: %+v vs %+v", info, lookup)
+		}
+	}
+
+	if _, err := r.InsertInfo(1, 2, "", 0); err != ErrIdExists {
+		t.Errorf("expected ErrIdExists, got: %v", err)
+	}
+	if _, err := r.InsertInfo(1, nextId(), "", -1); err != ErrNegativeLength {
+		t.Errorf("expected ErrNegativeLength, got: %v", err)
+	}
+}
+
+func TestCompareAndSplice(t *testing.T) {
+	r := buildIdRope(3)
+
+	if err := r.CompareAndSplice(1, 2, 10, "a", 1); err != nil {
+		t.Fatalf("expected CAS to succeed, got: %v", err)
+	}
+	if next := r.Info(1).Next; next != 10 {
+		t.Errorf("expected inserted after 1, next is: %v", next)
+	}
+
+	// another edit got in first, so the successor is no longer 2
+	if err := r.CompareAndSplice(1, 2, 11, "b", 1); err != ErrConflict {
+		t.Errorf("expected ErrConflict, got: %v", err)
+	}
+	if r.Find(11) != -1 {
+		t.Errorf("expected nothing inserted on conflict")
+	}
+
+	// the zero Id is expected after the last entry
+	if err := r.CompareAndSplice(3, 0, 12, "c", 1); err != nil {
+		t.Errorf("expected CAS at end to succeed, got: %v", err)
+	}
+	if err := r.CompareAndSplice(99, 0, 13, "d", 1); err != ErrBadAnchor {
+		t.Errorf("expected ErrBadAnchor, got: %v", err)
+	}
+}
+
+func TestInsertOrReplace(t *testing.T) {
+	r := New[int, SizedString]()
+
+	type op struct {
+		after, id int
+		data      SizedString
+	}
+	log := []op{{0, 1, "hello"}, {1, 2, " there"}, {1, 3, ","}, {2, 4, "!"}}
+	replay := func() {
+		for _, o := range log {
+			if err := r.InsertOrReplace(o.after, o.id, o.data, len(o.data)); err != nil {
+				t.Fatalf("couldn't replay op=%+v: %v", o, err)
+			}
+		}
+	}
+
+	replay()
+	first := collect(r)
+	replay()
+	if second := collect(r); !reflect.DeepEqual(first, second) {
+		t.Errorf("expected stable result after replay: %+v vs %+v", first, second)
+	}
+	if got := materialize(r); got != "hello, there!" {
+		t.Errorf("bad content: %q", got)
+	}
+
+	// update in-place keeps anchors
+	a, _ := r.AnchorOf(3)
+	r.InsertOrReplace(1, 3, ";", 1)
+	if got := materialize(r); got != "hello; there!" {
+		t.Errorf("bad content after update: %q", got)
+	}
+	if err := r.InsertAt(a, 5, "x"); err != nil {
+		t.Errorf("expected anchor to survive in-place update: %v", err)
+	}
+
+	// moving elsewhere
+	r.InsertOrReplace(0, 4, "!!", 2)
+	if got := materialize(r); got != "!!hello;x there" {
+		t.Errorf("bad content after move: %q", got)
+	}
+	if err := r.Validate(); err != nil {
+		t.Errorf("invalid after move: %v", err)
+	}
+	if r.LastId() != 2 {
+		t.Errorf("bad lastId after moving last: %d", r.LastId())
+	}
+
+	if err := r.InsertOrReplace(2, 2, "", 0); err != ErrBadAnchor {
+		t.Errorf("expected ErrBadAnchor for self, got: %v", err)
+	}
+	if err := r.InsertOrReplace(1, 0, "", 0); err != ErrIdExists {
+		t.Errorf("expected ErrIdExists for zero Id, got: %v", err)
+	}
+	if err := r.InsertOrReplace(1, 2, "", -1); err != ErrNegativeLength {
+		t.Errorf("expected ErrNegativeLength, got: %v", err)
+	}
+}
+
+func TestCompareAdjacent(t *testing.T) {
+	r := buildIdRope(10)
+
+	for id := range 10 {
+		if cmp, ok := r.Compare(id, id+1); !ok || cmp != -1 {
+			t.Errorf("expected %d before %d, got: %d", id, id+1, cmp)
+		}
+		if cmp, ok := r.Compare(id+1, id); !ok || cmp != 1 {
+			t.Errorf("expected %d after %d, got: %d", id+1, id, cmp)
+		}
+	}
+}
+
+func TestRoot(t *testing.T) {
+	r := NewRoot[int, SizedString]("meta")
+	r.Insert(0, 1, "hello")
+
+	if r.Root() != "meta" {
+		t.Errorf("bad root: %q", r.Root())
+	}
+
+	r.SetRoot("other meta")
+	if r.Root() != "other meta" || r.Info(0).Data != "other meta" {
+		t.Errorf("bad root after set: %q", r.Root())
+	}
+	if r.Len() != 5 || r.Count() != 1 || r.Info(0).Len != 0 {
+		t.Errorf("set root should not change len=%d or count=%d", r.Len(), r.Count())
+	}
+}
+
+func TestDeleteStream(t *testing.T) {
+	r := buildIdRope(10)
+
+	var seen []int
+	err := r.DeleteStream(2, 8, func(rm Removed[int, SizedString]) bool {
+		seen = append(seen, rm.Id)
+		return rm.Id != 5
+	})
+	if err != nil {
+		t.Fatalf("couldn't delete: %v", err)
+	}
+	if !reflect.DeepEqual(seen, []int{3, 4, 5}) {
+		t.Errorf("expected callbacks to stop after 5, got: %v", seen)
+	}
+	if r.Count() != 4 || r.Info(2).Next != 9 {
+		t.Errorf("delete should complete regardless: count=%d", r.Count())
+	}
+}
+
+func BenchmarkDeleteStream(b *testing.B) {
+	benchmarkLargeDelete(b, func(r Rope[int, SizedString], lastId int) {
+		r.DeleteStream(0, lastId, func(Removed[int, SizedString]) bool { return true })
+	})
+}
+
+func TestIsEmpty(t *testing.T) {
+	r := New[int, SizedString]()
+	if !r.IsEmpty() {
+		t.Errorf("expected new rope to be empty")
+	}
+
+	r.Insert(0, 1, "")
+	if r.IsEmpty() {
+		t.Errorf("expected rope with a zero-length entry to not be empty")
+	}
+
+	r.Delete(0, 1)
+	if !r.IsEmpty() {
+		t.Errorf("expected rope to be empty after delete")
+	}
+}
+
+func TestLastData(t *testing.T) {
+	r := New[int, SizedString]()
+	if _, ok := r.LastData(); ok {
+		t.Errorf("expected no last data on empty rope")
+	}
+
+	r.Insert(0, 1, "abc")
+	r.Insert(1, 2, "de")
+	if dl, ok := r.LastData(); !ok || dl.Data != "de" || dl.Len != 2 {
+		t.Errorf("expected last data=de, got: %+v %v", dl, ok)
+	}
+
+	r.PopLast()
+	if dl, ok := r.LastData(); !ok || dl.Data != "abc" {
+		t.Errorf("expected last data=abc, got: %+v %v", dl, ok)
+	}
+	r.PopLast()
+	if _, ok := r.LastData(); ok {
+		t.Errorf("expected no last data once emptied")
+	}
+}
+
+func TestFirstData(t *testing.T) {
+	r := New[int, SizedString]()
+	if _, ok := r.FirstData(); ok {
+		t.Errorf("expected no first data on empty rope")
+	}
+
+	r.Insert(0, 1, "abc")
+	if dl, ok := r.FirstData(); !ok || dl.Data != "abc" || dl.Len != 3 {
+		t.Errorf("expected first data=abc, got: %+v %v", dl, ok)
+	}
+
+	r.Insert(1, 2, "de")
+	r.Insert(0, 3, "f")
+	if dl, ok := r.FirstData(); !ok || dl.Data != "f" {
+		t.Errorf("expected first data=f, got: %+v %v", dl, ok)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	r := buildIdRope(5)
+	ro := r.ReadOnly()
+	if ro.Len() != 5 || ro.Count() != 5 || ro.Find(3) != 3 || ro.LastId() != 5 {
+		t.Errorf("bad read-only view: len=%d count=%d", ro.Len(), ro.Count())
+	}
+
+	// it sees later changes
+	r.Insert(5, 6, "y")
+	if ro.LastId() != 6 || !ro.Less(5, 6) {
+		t.Errorf("expected read-only view to see insert")
+	}
+
+	typ := reflect.TypeFor[ReadOnlyRope[int, SizedString]]()
+	for _, name := range []string{"Insert", "Delete", "Splice"} {
+		if _, ok := typ.MethodByName(name); ok {
+			t.Errorf("expected ReadOnlyRope to not have %s", name)
+		}
+	}
+}
+
+func TestFindOrNearest(t *testing.T) {
+	r := buildIdRope(5)
+	if got := r.FindOrNearest(3, 2); got != 3 {
+		t.Errorf("expected find(3)=3, got=%d", got)
+	}
+
+	// a cursor after 3 recovers to where 3 used to end, which is where 2 now ends
+	r.Delete(2, 3)
+	if got := r.FindOrNearest(3, 2); got != 2 {
+		t.Errorf("expected fallback to 2, got=%d", got)
+	}
+
+	r.Delete(1, 2)
+	if got := r.FindOrNearest(3, 2); got != -1 {
+		t.Errorf("expected -1 with both gone, got=%d", got)
+	}
+}
+
+func TestPop(t *testing.T) {
+	r := New[int, SizedString]()
+	if _, ok := r.PopFirst(); ok {
+		t.Errorf("expected PopFirst to fail on empty rope")
+	}
+	if _, ok := r.PopLast(); ok {
+		t.Errorf("expected PopLast to fail on empty rope")
+	}
+	if _, err := r.PopFirstE(); !errors.Is(err, ErrEmpty) {
+		t.Errorf("expected ErrEmpty, got: %v", err)
+	}
+	if _, err := r.PopLastE(); !errors.Is(err, ErrEmpty) {
+		t.Errorf("expected ErrEmpty, got: %v", err)
+	}
+
+	r.Insert(0, 1, "a")
+	r.Insert(1, 2, "bc")
+	r.Insert(2, 3, "def")
+	if rm, err := r.PopFirstE(); err != nil || rm.Id != 1 || rm.Data != "a" {
+		t.Errorf("expected to pop first, got: %+v %v", rm, err)
+	}
+	if rm, err := r.PopLastE(); err != nil || rm.Id != 3 || rm.Len != 3 {
+		t.Errorf("expected to pop last, got: %+v %v", rm, err)
+	}
+	if r.LastId() != 2 || r.Len() != 2 {
+		t.Errorf("expected only 2 left, got lastId=%d len=%d", r.LastId(), r.Len())
+	}
+	if rm, ok := r.PopLast(); !ok || rm.Id != 2 || !r.IsEmpty() {
+		t.Errorf("expected to pop the only entry, got: %+v %v", rm, ok)
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+}
+
+func TestContentCount(t *testing.T) {
+	r := New[int, SizedString]()
+	r.Insert(0, 1, "abc")
+	r.Insert(1, 2, "")
+	r.Insert(2, 3, "de")
+	r.Insert(3, 4, "")
+	if r.Count() != 4 || r.ContentCount() != 2 {
+		t.Errorf("expected count=4 content=2, got: %d %d", r.Count(), r.ContentCount())
+	}
+
+	r.Delete(2, 3)
+	if r.ContentCount() != 1 {
+		t.Errorf("expected content=1 after delete, got: %d", r.ContentCount())
+	}
+	r.Delete(1, 2)
+	if r.Count() != 2 || r.ContentCount() != 1 {
+		t.Errorf("expected count=2 content=1, got: %d %d", r.Count(), r.ContentCount())
+	}
+
+	// changing length in place
+	r.ReplaceKeepingId(4, "xyz", 3, nil)
+	r.ReplaceKeepingId(1, "", 0, nil)
+	if r.ContentCount() != 1 {
+		t.Errorf("expected content=1 after replace, got: %d", r.ContentCount())
+	}
+
+	other := buildIdRope(3)
+	other.Insert(3, 4, "")
+	c := buildIdRope(0)
+	c.Concat(other)
+	if c.ContentCount() != 3 {
+		t.Errorf("expected content=3 after concat, got: %d", c.ContentCount())
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+}
+
+func TestStrictLengths(t *testing.T) {
+	r := New[int, SizedString]()
+
+	// not strict by default
+	if _, err := r.InsertInfo(0, 1, "abc", 5); err != nil {
+		t.Errorf("expected mismatch to be allowed, got: %v", err)
+	}
+
+	r.StrictLengths(true)
+	if _, err := r.InsertInfo(1, 2, "abc", 2); err != ErrLengthMismatch {
+		t.Errorf("expected ErrLengthMismatch, got: %v", err)
+	}
+	if _, err := r.InsertInfo(1, 2, "abc", -1); err != ErrNegativeLength {
+		t.Errorf("expected ErrNegativeLength, got: %v", err)
+	}
+	if _, err := r.InsertInfo(1, 2, "abc", 3); err != nil {
+		t.Errorf("expected matching length to insert, got: %v", err)
+	}
+	if err := r.ReplaceKeepingId(2, "de", 3, nil); err != ErrLengthMismatch {
+		t.Errorf("expected ErrLengthMismatch on replace, got: %v", err)
+	}
+	if err := r.InsertOrReplace(2, 3, "x", 0); err != ErrLengthMismatch {
+		t.Errorf("expected ErrLengthMismatch on insert or replace, got: %v", err)
+	}
+	if r.Count() != 2 || r.Len() != 8 {
+		t.Errorf("expected no changes from mismatches: count=%d len=%d", r.Count(), r.Len())
+	}
+
+	// ropes which don't measure data have nothing to check against
+	n := NewRoot[int, string]("")
+	n.StrictLengths(true)
+	if _, err := n.InsertInfo(0, 1, "abc", 5); err != nil {
+		t.Errorf("expected no check without a length func, got: %v", err)
+	}
+}
+
+func TestIsHead(t *testing.T) {
+	r := buildIdRope(2)
+	if !r.IsHead(0) || r.IsHead(1) {
+		t.Errorf("expected only zero to be head")
+	}
+
+	s := NewWithSentinel[int, SizedString](-1, "")
+	s.Insert(-1, 0, "x")
+	if !s.IsHead(-1) || s.IsHead(0) {
+		t.Errorf("expected only sentinel to be head")
+	}
+}
+
+func TestSpliceDeleteThenInsert(t *testing.T) {
+	r := buildIdRope(5)
+
+	until := 4
+	insert := 10
+	removed, err := r.Splice(1, &until, &insert, "new")
+	if err != nil {
+		t.Fatalf("couldn't splice: %v", err)
+	}
+	if len(removed) != 3 || removed[0].Id != 2 || removed[2].Id != 4 {
+		t.Errorf("bad removed: %+v", removed)
+	}
+
+	if info := r.Info(10); info.Prev != 1 || info.Next != 5 {
+		t.Errorf("new node should be directly after anchor and before survivor: %+v", info)
+	}
+	if r.Find(10) != 4 || r.Len() != 5 || r.Count() != 3 {
+		t.Errorf("bad state: find=%d len=%d count=%d", r.Find(10), r.Len(), r.Count())
+	}
+
+	// also at the end of the rope
+	until = 5
+	insert = 11
+	r.Splice(10, &until, &insert, "end")
+	if r.LastId() != 11 || r.Info(11).Prev != 10 {
+		t.Errorf("new node should replace deleted tail: lastId=%d", r.LastId())
+	}
+}
+
+type runEntry = struct {
+	Id   int
+	Data SizedString
+	Len  int
+}
+
+func makeRun(count int) []runEntry {
+	out := make([]runEntry, count)
+	for i := range out {
+		out[i] = runEntry{Id: nextId(), Data: "ab", Len: 2}
+	}
+	return out
+}
+
+func TestInsertRun(t *testing.T) {
+	r := buildIdRope(3)
+	run := makeRun(50)
+	if err := r.InsertRun(2, run); err != nil {
+		t.Fatalf("couldn't insert run: %v", err)
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+
+	var got []int
+	for id := range r.Iter(2) {
+		got = append(got, id)
+	}
+	if len(got) != 51 || got[0] != run[0].Id || got[49] != run[49].Id || got[50] != 3 {
+		t.Errorf("expected run in order before 3, got: %v", got)
+	}
+	if r.Len() != 103 {
+		t.Errorf("expected len=103, got=%d", r.Len())
+	}
+
+	// a duplicate part way through removes what was added
+	bad := makeRun(10)
+	bad[7].Id = 1
+	if err := r.InsertRun(0, bad); err != ErrIdExists {
+		t.Errorf("expected ErrIdExists, got: %v", err)
+	}
+	bad = makeRun(10)
+	bad[9].Len = -1
+	if err := r.InsertRun(3, bad); err != ErrNegativeLength {
+		t.Errorf("expected ErrNegativeLength, got: %v", err)
+	}
+	if r.Count() != 53 || r.Len() != 103 || r.LastId() != 3 {
+		t.Errorf("expected failed runs to be removed: count=%d len=%d", r.Count(), r.Len())
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+}
+
+func BenchmarkInsertRun(b *testing.B) {
+	for b.Loop() {
+		b.StopTimer()
+		r := buildIdRope(1000)
+		run := makeRun(10_000)
+		b.StartTimer()
+
+		r.InsertRun(500, run)
+	}
+}
+
+func BenchmarkInsertRunEach(b *testing.B) {
+	for b.Loop() {
+		b.StopTimer()
+		r := buildIdRope(1000)
+		run := makeRun(10_000)
+		b.StartTimer()
+
+		after := 500
+		for _, e := range run {
+			r.InsertInfo(after, e.Id, e.Data, e.Len)
+			after = e.Id
+		}
+	}
+}
+
+func TestSpliceWithFallback(t *testing.T) {
+	r := buildIdRope(5)
+
+	// the intended anchor is still here
+	insert := 10
+	if _, err := r.SpliceWithFallback(3, 2, nil, &insert, "y"); err != nil || r.Info(10).Prev != 3 {
+		t.Errorf("expected insert after 3, got prev=%d err=%v", r.Info(10).Prev, err)
+	}
+
+	// the intended anchor was deleted, so use its former predecessor
+	r.Delete(2, 3)
+	insert = 11
+	if _, err := r.SpliceWithFallback(3, 2, nil, &insert, "z"); err != nil || r.Info(11).Prev != 2 {
+		t.Errorf("expected insert after 2, got prev=%d err=%v", r.Info(11).Prev, err)
+	}
+
+	insert = 12
+	if _, err := r.SpliceWithFallback(3, 3, nil, &insert, "z"); err != ErrBadAnchor {
+		t.Errorf("expected ErrBadAnchor with both missing, got: %v", err)
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+}
+
+func TestSpliceInto(t *testing.T) {
+	for _, buf := range [][]Removed[int, SizedString]{nil, make([]Removed[int, SizedString], 5, 16)} {
+		r := buildIdRope(10)
+		until := 4
+		removed, err := r.SpliceInto(buf, 1, &until, nil, "")
+		if err != nil {
+			t.Fatalf("couldn't splice: %v", err)
+		}
+		var ids []int
+		for _, rm := range removed {
+			ids = append(ids, rm.Id)
+		}
+		if !reflect.DeepEqual(ids, []int{2, 3, 4}) {
+			t.Errorf("expected only the removed entries, got: %v", ids)
+		}
+		if buf != nil && &removed[0] != &buf[0] {
+			t.Errorf("expected buf to be reused")
+		}
+
+		// nothing is appended on error
+		removed, err = r.SpliceInto(removed, -1, &until, nil, "")
+		if err != ErrBadAnchor || len(removed) != 0 {
+			t.Errorf("expected ErrBadAnchor and empty, got: %v %v", removed, err)
+		}
+	}
+}
+
+func BenchmarkDeleteSplice(b *testing.B) {
+	r := buildIdRope(1000)
+	for b.Loop() {
+		id := nextId()
+		r.Insert(500, id, "x")
+		r.Splice(500, &id, nil, "")
+	}
+}
+
+func BenchmarkDeleteSpliceInto(b *testing.B) {
+	r := buildIdRope(1000)
+	var buf []Removed[int, SizedString]
+	for b.Loop() {
+		id := nextId()
+		r.Insert(500, id, "x")
+		buf, _ = r.SpliceInto(buf, 500, &id, nil, "")
+	}
+}
+
+func TestTouchRange(t *testing.T) {
+	r := buildIdRope(10)
+
+	for _, tt := range []struct{ after, until, want int }{{0, 10, 10}, {2, 5, 3}, {4, 4, 0}} {
+		if got, err := r.TouchRange(tt.after, tt.until); err != nil || got != tt.want {
+			t.Errorf("TouchRange(%d, %d) = %d/%v, want %d", tt.after, tt.until, got, err, tt.want)
+		}
+	}
+	if _, err := r.TouchRange(5, 2); err != ErrUntilNotAfter {
+		t.Errorf("expected ErrUntilNotAfter for backwards range, got: %v", err)
+	}
+	if _, err := r.TouchRange(99, 2); err != ErrBadAnchor {
+		t.Errorf("expected ErrBadAnchor, got: %v", err)
+	}
+	if r.Count() != 10 {
+		t.Errorf("expected nothing removed, got count=%d", r.Count())
+	}
+}
+
+func TestSpliceWithResult(t *testing.T) {
+	r, _ := BuildFromSlice(randomEntries(200))
+	ids := []int{0}
+	for id := range r.Iter(0) {
+		ids = append(ids, id)
+	}
+
+	for range 100 {
+		i := rand.IntN(len(ids))
+		j := i + rand.IntN(min(4, len(ids)-i))
+		afterId, untilId, insertId := ids[i], ids[j], nextId()
+
+		start, before := r.Find(afterId), r.Len()
+		res, err := r.SpliceWithResult(afterId, &untilId, &insertId, SizedString("abcd"[:rand.IntN(5)]))
+		if err != nil {
+			t.Fatalf("couldn't splice: %v", err)
+		}
+		if res.Start != start || res.Delta != r.Len()-before || len(res.Removed) != j-i {
+			t.Fatalf("bad result: %+v, wanted start=%d delta=%d", res, start, r.Len()-before)
+		}
+
+		ids = append(ids[:i+1], append([]int{insertId}, ids[j+1:]...)...)
+	}
+
+	if _, err := r.SpliceWithResult(-1, nil, nil, ""); err != ErrBadAnchor {
+		t.Errorf("expected ErrBadAnchor, got: %v", err)
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+}
+
+func TestSpliceAudit(t *testing.T) {
+	r := New[int, SizedString]()
+	for i, s := range []SizedString{"abc", "de", "", "fghi", "j"} {
+		r.Insert(i, i+1, s)
+	}
+
+	until, insert := 4, 10
+	removed, err := r.SpliceAudit(1, &until, &insert, "xyz")
+	if err != nil {
+		t.Fatalf("couldn't splice: %v", err)
+	}
+
+	type at struct{ id, pos int }
+	var got []at
+	for _, rm := range removed {
+		got = append(got, at{rm.Id, rm.Position})
+	}
+	if want := []at{{2, 3}, {3, 5}, {4, 5}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected positions before removal %v, got: %v", want, got)
+	}
+	if removed[2].Data != "fghi" || removed[2].Len != 4 {
+		t.Errorf("expected removed entry, got: %+v", removed[2])
+	}
+
+	if _, err := r.SpliceAudit(99, nil, nil, ""); err != ErrBadAnchor {
+		t.Errorf("expected ErrBadAnchor, got: %v", err)
+	}
+}
+
+func TestDeletePreview(t *testing.T) {
+	r := New[int, SizedString]()
+	ids := []int{0}
+	for range 200 {
+		id := nextId()
+		r.Insert(ids[rand.IntN(len(ids))], id, SizedString("abcd"[:rand.IntN(5)]))
+		ids = append(ids, id)
+	}
+
+	for range 20 {
+		a, b := ids[rand.IntN(len(ids))], ids[rand.IntN(len(ids))]
+		if cmp, _ := r.Compare(a, b); cmp > 0 {
+			if _, _, err := r.DeletePreview(a, b); err != ErrUntilNotAfter {
+				t.Errorf("expected ErrUntilNotAfter, got: %v", err)
+			}
+			a, b = b, a
+		}
+
+		count, totalLen, err := r.DeletePreview(a, b)
+		if err != nil {
+			t.Fatalf("couldn't preview: %v", err)
+		}
+		removed, _ := r.Delete(a, b)
+		var removedLen int
+		for _, rm := range removed {
+			removedLen += rm.Len
+		}
+		if count != len(removed) || totalLen != removedLen {
+			t.Fatalf("preview=%d/%d, but removed=%d/%d", count, totalLen, len(removed), removedLen)
+		}
+
+		ids = ids[:0]
+		ids = append(ids, 0)
+		for id := range r.Iter(0) {
+			ids = append(ids, id)
+		}
+	}
+
+	if _, _, err := r.DeletePreview(-1, 0); err != ErrBadAnchor {
+		t.Errorf("expected ErrBadAnchor, got: %v", err)
+	}
+}
+
+func TestDeleteRanges(t *testing.T) {
+	r := buildIdRope(20)
+
+	// given out of order, including adjacent ranges
+	removed, err := r.DeleteRanges([][2]int{{14, 16}, {2, 4}, {8, 10}, {10, 11}})
+	if err != nil {
+		t.Fatalf("couldn't delete: %v", err)
+	}
+	var ids []int
+	for _, rm := range removed {
+		ids = append(ids, rm.Id)
+	}
+	if !reflect.DeepEqual(ids, []int{3, 4, 9, 10, 11, 15, 16}) {
+		t.Errorf("expected removed in position order, got: %v", ids)
+	}
+	if r.Count() != 13 {
+		t.Errorf("expected count=13, got=%d", r.Count())
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+
+	// nothing is removed if any range is bad
+	for _, ranges := range [][][2]int{
+		{{1, 6}, {5, 7}},
+		{{1, 2}, {7, 5}},
+		{{1, 2}, {100, 101}},
+	} {
+		if _, err := r.DeleteRanges(ranges); err == nil {
+			t.Errorf("expected error for ranges=%v", ranges)
+		}
+	}
+	if r.Count() != 13 {
+		t.Errorf("expected nothing removed on error, count=%d", r.Count())
+	}
+}
+
+func TestDeleteWhere(t *testing.T) {
+	entries := randomEntries(500)
+	for i := range entries {
+		if i%3 != 0 {
+			entries[i].Len = 0 // runs of zero-length entries
+		}
+	}
+	zeroLen := func(id int, dl DataLen[SizedString]) bool { return dl.Len == 0 }
+
+	r, _ := BuildFromSlice(entries)
+	removed, err := r.DeleteWhere(zeroLen)
+	if err != nil {
+		t.Fatalf("couldn't delete: %v", err)
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+
+	// compare to collecting ids and then deleting each
+	manual, _ := BuildFromSlice(entries)
+	var ids []int
+	for id, dl := range manual.Iter(0) {
+		if zeroLen(id, dl) {
+			ids = append(ids, id)
+		}
+	}
+	var want []Removed[int, SizedString]
+	for _, id := range ids {
+		rm, _ := manual.Delete(manual.Info(id).Prev, id)
+		want = append(want, rm...)
+	}
+
+	if !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed didn't match two-pass delete: %d vs %d", len(removed), len(want))
+	}
+	if !r.SameOrder(manual) || r.Count() != manual.Count() || r.ContentCount() != r.Count() {
+		t.Errorf("rope didn't match two-pass delete")
+	}
+}
+
+func TestPrewarmPool(t *testing.T) {
+	insertAllocs := func(r Rope[int, SizedString]) float64 {
+		// AllocsPerRun runs once more to warm up, and the map of Ids only has room for a few before it grows
+		id := 0
+		return testing.AllocsPerRun(1, func() {
+			for range 3 {
+				r.Insert(id, id+1, "x")
+				id++
+			}
+		})
+	}
+
+	// seeded so no entry is taller than prewarmed nodes have room for
+	newRope := func() Rope[int, SizedString] {
+		r := New[int, SizedString]()
+		r.Reseed(1)
+		return r
+	}
+
+	if allocs := insertAllocs(newRope()); allocs == 0 {
+		t.Errorf("expected inserts to allocate without prewarming")
+	}
+
+	r := newRope()
+	r.PrewarmPool(100)
+	if got := len(r.(*ropeImpl[int, SizedString]).nodePool); got != poolSize {
+		t.Errorf("expected pool capped at %d, got: %d", poolSize, got)
+	}
+	if allocs := insertAllocs(r); allocs != 0 {
+		t.Errorf("expected no allocs after prewarming, got: %v", allocs)
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+}
+
+func TestHeadLevelsPreallocated(t *testing.T) {
+	// the head has room for every level up front, so inserting never reallocates its levels
+	r := New[int, SizedEmpty]()
+	impl := r.(*ropeImpl[int, SizedEmpty])
+	first := &impl.head.levels[0]
+
+	ids := []int{0}
+	for range 20_000 {
+		id := nextId()
+		r.Insert(ids[rand.IntN(len(ids))], id, SizedEmpty(rand.IntN(4)))
+		ids = append(ids, id)
+	}
+	if impl.height < 10 {
+		t.Fatalf("expected rope to grow taller, got height=%d", impl.height)
+	}
+	if &impl.head.levels[0] != first || cap(impl.head.levels) != maxHeight {
+		t.Errorf("expected head levels not to be reallocated")
+	}
+}
+
+func TestZeroLengthTail(t *testing.T) {
+	r := New[int, SizedString]()
+	r.Insert(0, 1, "abc")
+
+	// each zero-length entry at the end becomes the new last entry, at the same position
+	for id := 2; id < 6; id++ {
+		if err := r.Insert(r.LastId(), id, ""); err != nil {
+			t.Fatalf("couldn't insert: %v", err)
+		}
+		if r.LastId() != id {
+			t.Errorf("expected lastId=%d, got=%d", id, r.LastId())
+		}
+		if r.Find(id) != 3 {
+			t.Errorf("expected find(%d)=3, got=%d", id, r.Find(id))
+		}
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+
+	// inserting after the head of a rope with no length doesn't make a new last entry
+	z := New[int, SizedString]()
+	z.Insert(0, 1, "")
+	z.Insert(1, 2, "")
+	z.Insert(0, 3, "")
+	if z.LastId() != 2 {
+		t.Errorf("expected lastId=2, got=%d", z.LastId())
+	}
+	if err := z.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+}
+
+func TestTailAfterMiddleDelete(t *testing.T) {
+	r := buildIdRope(10)
+	r.Insert(10, 11, "")
+	r.Delete(3, 7)
+	if r.LastId() != 11 {
+		t.Errorf("expected lastId=11 after middle delete, got=%d", r.LastId())
+	}
+
+	// and arbitrary deletes, with zero-length entries, keep the tail
+	ids := []int{0}
+	for range 2000 {
+		if len(ids) > 2 && rand.IntN(3) == 0 {
+			a, b := 1+rand.IntN(len(ids)-1), 1+rand.IntN(len(ids)-1)
+			if cmp, _ := r.Compare(ids[a], ids[b]); cmp > 0 {
+				a, b = b, a
+			}
+			r.Delete(r.Info(ids[a]).Prev, ids[b])
+			ids = ids[:1]
+			for id := range r.Iter(0) {
+				ids = append(ids, id)
+			}
+		} else {
+			id := nextId()
+			r.Insert(ids[rand.IntN(len(ids))], id, SizedString("ab"[:rand.IntN(3)]))
+			ids = append(ids, id)
+		}
+		if err := r.Validate(); err != nil {
+			t.Fatalf("invalid: %v", err)
+		}
+	}
+}
+
+func TestNewSmall(t *testing.T) {
+	r := NewSmall[int, SizedString]("")
+	for i := range 2000 {
+		r.Insert(i, i+1, "x")
+	}
+
+	if err := r.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+	if hist := r.HeightHistogram(); len(hist) > smallMaxHeight {
+		t.Errorf("expected heights capped at %d, got: %v", smallMaxHeight, hist)
+	}
+	if r.Find(1000) != 1000 {
+		t.Errorf("bad find: %d", r.Find(1000))
+	}
+	if id, _ := r.ByPosition(1500, false); id != 1500 {
+		t.Errorf("bad byPosition: %d", id)
+	}
+
+	// concat of a taller rope raises the cap to fit, with room for the head to grow without reallocating
+	tall := New[int, SizedString]()
+	for i := range 20_000 {
+		tall.Insert(0, 10_000+i, "x")
+	}
+	if err := r.Concat(tall); err != nil {
+		t.Fatalf("couldn't concat: %v", err)
+	} else if err := r.Validate(); err != nil {
+		t.Fatalf("invalid after concat: %v", err)
+	}
+	impl := r.(*ropeImpl[int, SizedString])
+	if impl.height <= smallMaxHeight || impl.heightLimit != impl.height || cap(impl.head.levels) < impl.heightLimit {
+		t.Errorf("expected height cap to grow to fit, got height=%d limit=%d", impl.height, impl.heightLimit)
+	}
+}
+
+func BenchmarkNewRoot(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		NewRoot[int, SizedString]("")
+	}
+}
+
+func BenchmarkNewSmall(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		NewSmall[int, SizedString]("")
+	}
+}
+
+// tallSource is a rand.Source which often gives very tall heights.
+type tallSource struct {
+	count int
+}
+
+func (t *tallSource) Uint64() uint64 {
+	t.count++
+	if t.count%2 == 0 {
+		return 1 << (36 + t.count%10)
+	}
+	return rand.Uint64()
+}
+
+func TestNewWithMaxHeight(t *testing.T) {
+	r := NewWithMaxHeight[int, SizedString]("", 48)
+	r.(*ropeImpl[int, SizedString]).rng = rand.New(&tallSource{})
+
+	ids := []int{0}
+	for range 500 {
+		newId := nextId()
+		r.Insert(ids[rand.IntN(len(ids))], newId, SizedString("abc"[:rand.IntN(3)]))
+		ids = append(ids, newId)
+	}
+	r.Delete(ids[10], ids[20])
+
+	if err := r.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+	hist := r.HeightHistogram()
+	if len(hist) <= maxHeight || len(hist) > 48 {
+		t.Errorf("expected heights over default up to 48, got: %d", len(hist))
+	}
+
+	var prev int
+	for id := range r.Iter(0) {
+		if cmp, _ := r.Compare(prev, id); cmp != -1 {
+			t.Fatalf("bad compare for prev=%d id=%d", prev, id)
+		}
+		if pos := r.Find(id); r.RankLogN(id) < 0 || pos < 0 {
+			t.Fatalf("bad find for id=%d", id)
+		}
+		prev = id
+	}
+	if r.BalanceFactor() <= 0 {
+		t.Errorf("bad balance factor")
+	}
+
+	// the default height stays on the stack, so the hot path doesn't allocate
+	d := New[int, SizedString]()
+	for i := range 1_000 {
+		d.Insert(i, i+1, SizedString("abc"[:rand.IntN(3)]))
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		d.Insert(500, 2_000, "ab")
+		d.DeleteCount(500, 2_000)
+		d.BalanceFactor()
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got: %v", allocs)
+	}
+}
+
+func TestBuiltinSizers(t *testing.T) {
+	b := New[int, Bytes]()
+	b.Insert(0, 1, Bytes("hello"))
+	b.Insert(1, 2, Bytes{})
+	if b.Len() != 5 || b.Info(2).Len != 0 {
+		t.Errorf("bad Bytes lengths: %d", b.Len())
+	}
+
+	r := New[int, Runes]()
+	r.Insert(0, 1, Runes("héllo"))
+	if r.Len() != 5 {
+		t.Errorf("bad Runes length: %d", r.Len())
+	}
+
+	c := New[int, Chars]()
+	c.Insert(0, 1, Chars("héllo"))
+	if c.Len() != 6 {
+		t.Errorf("bad Chars length: %d", c.Len())
+	}
+}
+
+func TestNewWithLenFunc(t *testing.T) {
+	r := NewWithLenFunc[int](func(b []byte) int { return len(b) })
+	r.Insert(0, 1, []byte("hello"))
+	r.Insert(1, 2, nil)
+	r.Insert(2, 3, []byte(" there"))
+
+	if r.Len() != 11 || r.Info(1).Len != 5 || r.Info(2).Len != 0 {
+		t.Errorf("expected lengths from func, got len=%d", r.Len())
+	}
+
+	// explicit lengths still win
+	r.InsertInfo(3, 4, []byte("!"), 3)
+	if r.Find(4) != 14 {
+		t.Errorf("expected explicit length, got: %d", r.Find(4))
+	}
+
+	// the func is used even if T is a Sizer
+	s := NewWithLenFunc[int](func(s SizedString) int { return 2 * len(s) })
+	s.Insert(0, 1, "abc")
+	if s.Len() != 6 {
+		t.Errorf("expected func to override Sizer, got: %d", s.Len())
+	}
+}
+
+func BenchmarkInsertNotSizer(b *testing.B) {
+	var root [2]int
+	r := NewRoot[int](root)
+	for b.Loop() {
+		r.Insert(0, nextId(), [2]int{1, 2})
+	}
+}
+
+func TestSizerDetection(t *testing.T) {
+	// interface types are checked per-entry
+	a := NewRoot[int, any](nil)
+	a.Insert(0, 1, SizedString("abc"))
+	a.Insert(1, 2, 123)
+	if a.Len() != 3 {
+		t.Errorf("expected Sizer detected in interface, got: %d", a.Len())
+	}
+
+	// concrete Sizer types work even if not built via New
+	s := NewRoot[int](SizedString(""))
+	s.Insert(0, 1, "hello")
+	if s.Len() != 5 {
+		t.Errorf("expected Sizer detected, got: %d", s.Len())
+	}
+
+	n := NewRoot[int](0)
+	n.Insert(0, 1, 123)
+	if n.Len() != 0 {
+		t.Errorf("expected zero length for non-Sizer, got: %d", n.Len())
+	}
+}
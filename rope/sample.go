@@ -0,0 +1,34 @@
+package rope
+
+import (
+	"math/rand/v2"
+)
+
+func (r *ropeImpl[Id, T]) SampleByLength(rng *rand.Rand) (id Id, offset int) {
+	if r.len == 0 {
+		return r.head.id, 0
+	}
+
+	var p int
+	if rng != nil {
+		p = rng.IntN(r.len)
+	} else {
+		p = rand.IntN(r.len)
+	}
+	return r.ByPosition(p, true)
+}
+
+func (r *ropeImpl[Id, T]) SampleNode(rng *rand.Rand) (id Id, ok bool) {
+	count := r.Count()
+	if count == 0 {
+		return id, false
+	}
+
+	var n int
+	if rng != nil {
+		n = rng.IntN(count)
+	} else {
+		n = rand.IntN(count)
+	}
+	return r.SelectLogN(n + 1)
+}
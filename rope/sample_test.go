@@ -0,0 +1,66 @@
+package rope
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestSampleByLength(t *testing.T) {
+	r := New[int, SizedString]()
+	if id, _ := r.SampleByLength(nil); id != 0 {
+		t.Errorf("expected zero Id for empty rope, got: %d", id)
+	}
+
+	lengths := map[int]int{1: 1, 2: 0, 3: 4, 4: 10, 5: 0, 6: 5}
+	for id := 1; id <= 6; id++ {
+		r.Insert(id-1, id, SizedString(make([]byte, lengths[id])))
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	const samples = 20_000
+	counts := map[int]int{}
+	for range samples {
+		id, offset := r.SampleByLength(rng)
+		if offset < 1 || offset > lengths[id] {
+			t.Fatalf("bad offset=%d for id=%d", offset, id)
+		}
+		counts[id]++
+	}
+
+	for id, length := range lengths {
+		want := samples * float64(length) / float64(r.Len())
+		if math.Abs(float64(counts[id])-want) > samples*0.02 {
+			t.Errorf("id=%d: expected ~%.0f samples, got=%d", id, want, counts[id])
+		}
+	}
+}
+
+func TestSampleNode(t *testing.T) {
+	r := New[int, SizedString]()
+	if _, ok := r.SampleNode(nil); ok {
+		t.Errorf("expected no sample from empty rope")
+	}
+
+	// lengths don't matter, including zero
+	for id := 1; id <= 10; id++ {
+		r.Insert(id-1, id, SizedString(make([]byte, id%3)))
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	const samples = 20_000
+	counts := map[int]int{}
+	for range samples {
+		id, ok := r.SampleNode(rng)
+		if !ok || id == 0 {
+			t.Fatalf("bad sample: %d %v", id, ok)
+		}
+		counts[id]++
+	}
+
+	for id := 1; id <= 10; id++ {
+		if math.Abs(float64(counts[id])-samples/10) > samples*0.01 {
+			t.Errorf("id=%d: expected ~%d samples, got=%d", id, samples/10, counts[id])
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package rope
+
+import (
+	"math"
+)
+
+func (r *ropeImpl[Id, T]) HeightHistogram() (out []int) {
+	for node := r.head.levels[0].next; node != nil; node = node.levels[0].next {
+		h := len(node.levels)
+		for len(out) < h {
+			out = append(out, 0)
+		}
+		out[h-1]++
+	}
+	return out
+}
+
+func (r *ropeImpl[Id, T]) BalanceFactor() float64 {
+	count := r.Count()
+	if count < 2 {
+		return 1
+	}
+
+	// Find steps to the prev of each node's top level, which is the most recent node at least as tall.
+	// Track the steps for the most recent node at each level, so each node's steps derive from it.
+	var stepsStack [maxHeight]int
+	steps := stepsStack[:]
+	if r.height > maxHeight {
+		steps = make([]int, r.height)
+	}
+	var total int
+	for node := r.head.levels[0].next; node != nil; node = node.levels[0].next {
+		h := len(node.levels)
+		s := steps[h-1] + 1
+		total += s
+		for i := range h {
+			steps[i] = s
+		}
+	}
+
+	return float64(total) / float64(count) / math.Log2(float64(count))
+}
+
+func (r *ropeImpl[Id, T]) LevelWalk(level int, fn func(id Id, subtreesize int) bool) {
+	if level < 0 || level >= r.height {
+		return
+	}
+	for node := &r.head; node != nil; node = node.levels[level].next {
+		if !fn(node.id, node.levels[level].subtreesize) {
+			return
+		}
+	}
+}
+
+func (r *ropeImpl[Id, T]) CountWhere(pred func(DataLen[T]) bool) (count int) {
+	for node := r.head.levels[0].next; node != nil; node = node.levels[0].next {
+		if pred(node.dl) {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *ropeImpl[Id, T]) SubtreeSize(id Id, level int) (int, bool) {
+	node := r.byId[id]
+	if node == nil || level < 0 || level >= len(node.levels) {
+		return 0, false
+	}
+	return node.levels[level].subtreesize, true
+}
+
+func (r *ropeImpl[Id, T]) Metrics() RopeMetrics {
+	out := RopeMetrics{Inserts: r.inserts, Deletes: r.deletes}
+	if count := r.Count(); count != 0 {
+		out.AverageLen = float64(r.len) / float64(count)
+	}
+	return out
+}
+
+func (r *ropeImpl[Id, T]) FragmentationReport(threshold int) (out FragReport) {
+	out.CoalescedCount = r.Count()
+
+	var run int
+	for node := r.head.levels[0].next; ; node = node.levels[0].next {
+		if node != nil && node.dl.Len != 0 && node.dl.Len < threshold {
+			out.Small++
+			run++
+			continue
+		}
+		if run > 1 {
+			out.Runs++
+			out.CoalescedCount -= run - 1
+		}
+		run = 0
+		if node == nil {
+			return out
+		}
+	}
+}
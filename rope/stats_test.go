@@ -0,0 +1,389 @@
+package rope
+
+import (
+	"bytes"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHeightHistogram(t *testing.T) {
+	r := New[int, SizedString]()
+	if hist := r.HeightHistogram(); len(hist) != 0 {
+		t.Errorf("expected empty histogram, got: %v", hist)
+	}
+
+	const count = 1_000_000
+	r, _ = BuildFromSlice(randomEntries(count))
+	hist := r.HeightHistogram()
+
+	var total int
+	for _, c := range hist {
+		total += c
+	}
+	if total != count {
+		t.Fatalf("histogram should sum to count=%d, got=%d", count, total)
+	}
+
+	// each level should have half the nodes of the one below; only check levels with enough nodes to be meaningful
+	for i := 1; i < len(hist) && hist[i-1] >= 10_000; i++ {
+		ratio := float64(hist[i]) / float64(hist[i-1])
+		if math.Abs(ratio-0.5) > 0.05 {
+			t.Errorf("bad ratio at height=%d: %v (hist=%v)", i+1, ratio, hist)
+		}
+	}
+}
+
+func TestBalanceFactor(t *testing.T) {
+	r := New[int, SizedString]()
+	if f := r.BalanceFactor(); f != 1 {
+		t.Errorf("expected empty rope to have factor=1, got: %v", f)
+	}
+
+	r, _ = BuildFromSlice(randomEntries(100_000))
+	f := r.BalanceFactor()
+	t.Logf("factor=%v", f)
+	if f < 0.75 || f > 1.25 {
+		t.Errorf("expected factor near 1, got: %v", f)
+	}
+}
+
+func TestReseed(t *testing.T) {
+	r := New[int, SizedString]()
+
+	build := func() []int {
+		r.Reseed(1234)
+		for range 1000 {
+			r.Insert(r.LastId(), nextId(), "x")
+		}
+		hist := r.HeightHistogram()
+		r.Delete(0, r.LastId())
+		return hist
+	}
+
+	first := build()
+	second := build()
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("expected same histogram after reseed, got: %v vs %v", first, second)
+	}
+}
+
+// flatSource always returns all one bits, so every node is given a height of one.
+type flatSource struct{}
+
+func (flatSource) Uint64() uint64 { return ^uint64(0) }
+
+func TestRebalance(t *testing.T) {
+	r := New[int, SizedString]()
+	r.(*ropeImpl[int, SizedString]).rng = rand.New(flatSource{})
+	ids := []int{0}
+	for range 2000 {
+		id := nextId()
+		r.Insert(ids[rand.IntN(len(ids))], id, SizedString("abcd"[:rand.IntN(5)]))
+		ids = append(ids, id)
+	}
+	if hist := r.HeightHistogram(); len(hist) != 1 {
+		t.Fatalf("expected degraded rope, got heights: %v", hist)
+	}
+	if f := r.BalanceFactor(); f < 10 {
+		t.Fatalf("expected poor balance, got: %v", f)
+	}
+
+	answers := func() (out []int) {
+		for _, id := range ids {
+			out = append(out, r.Find(id), r.RankLogN(id))
+		}
+		for pos := range r.Len() + 1 {
+			id, offset := r.ByPosition(pos, pos%2 == 0)
+			out = append(out, id, offset)
+		}
+		return out
+	}
+	before := answers()
+	anchor, _ := r.AnchorOf(ids[1])
+
+	r.Reseed(1)
+	r.Rebalance()
+	if err := r.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+	if f := r.BalanceFactor(); f < 0.75 || f > 1.25 {
+		t.Errorf("expected factor near 1, got: %v", f)
+	}
+	if !reflect.DeepEqual(before, answers()) {
+		t.Errorf("expected the same answers after rebalance")
+	}
+	if err := r.InsertAt(anchor, nextId(), "x"); err != nil {
+		t.Errorf("expected anchor to stay valid, got: %v", err)
+	}
+}
+
+func TestLevelWalk(t *testing.T) {
+	entries := randomEntries(1000)
+	r, _ := BuildFromSlice(entries)
+
+	// level zero has every node
+	want := []int{0}
+	for _, e := range entries {
+		want = append(want, e.Id)
+	}
+	var got []int
+	r.LevelWalk(0, func(id int, subtreesize int) bool {
+		got = append(got, id)
+		return true
+	})
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("level zero walk didn't match entries")
+	}
+
+	// higher levels have only nodes at least that tall, in order, and their sizes still sum to Len
+	hist := r.HeightHistogram()
+	for level := 1; level < len(hist); level++ {
+		var expected []int
+		for id := range r.Iter(0) {
+			if len(r.(*ropeImpl[int, SizedString]).byId[id].levels) > level {
+				expected = append(expected, id)
+			}
+		}
+
+		var ids []int
+		var total int
+		r.LevelWalk(level, func(id int, subtreesize int) bool {
+			if id != 0 {
+				ids = append(ids, id)
+			}
+			total += subtreesize
+			return true
+		})
+		if !reflect.DeepEqual(expected, ids) {
+			t.Errorf("bad nodes at level=%d", level)
+		}
+		if total != r.Len() {
+			t.Errorf("bad total at level=%d: wanted=%d, got=%d", level, r.Len(), total)
+		}
+	}
+
+	var calls int
+	r.LevelWalk(0, func(int, int) bool {
+		calls++
+		return calls < 3
+	})
+	r.LevelWalk(100, func(int, int) bool {
+		calls++
+		return true
+	})
+	if calls != 3 {
+		t.Errorf("expected walk to stop early and skip unused levels, got calls=%d", calls)
+	}
+}
+
+func TestCountWhere(t *testing.T) {
+	r := New[int, SizedString]()
+	for i, s := range []string{"a", "hello", "", "abcd", "there", "longer words"} {
+		r.Insert(i, i+1, SizedString(s))
+	}
+
+	long := func(dl DataLen[SizedString]) bool { return dl.Len > 4 }
+	if c := r.CountWhere(long); c != 3 {
+		t.Errorf("expected 3 long entries, got: %d", c)
+	}
+	if c := r.CountWhere(func(DataLen[SizedString]) bool { return true }); c != r.Count() {
+		t.Errorf("expected all entries, got: %d", c)
+	}
+}
+
+// fixedSource is a rand.Source which repeats the given values.
+type fixedSource struct {
+	values []uint64
+	index  int
+}
+
+func (f *fixedSource) Uint64() uint64 {
+	v := f.values[f.index%len(f.values)]
+	f.index++
+	return v
+}
+
+func TestSubtreeSize(t *testing.T) {
+	r := New[int, SizedString]()
+
+	// heights are 1, 3, 2, 1, read from the lowest bit up
+	r.(*ropeImpl[int, SizedString]).rng = rand.New(&fixedSource{values: []uint64{0b1_10_100_1}})
+	for i, s := range []string{"a", "bb", "ccc", "dddd"} {
+		r.Insert(i, i+1, SizedString(s))
+	}
+
+	type query struct {
+		id, level int
+	}
+	expected := map[query]int{
+		{0, 0}: 0, {0, 1}: 1, {0, 2}: 1,
+		{1, 0}: 1,
+		{2, 0}: 2, {2, 1}: 2, {2, 2}: 9,
+		{3, 0}: 3, {3, 1}: 7,
+		{4, 0}: 4,
+	}
+	for q, want := range expected {
+		if got, ok := r.SubtreeSize(q.id, q.level); !ok || got != want {
+			t.Errorf("bad size for id=%d level=%d: wanted=%d, got=%d/%v", q.id, q.level, want, got, ok)
+		}
+	}
+
+	for _, q := range []query{{1, 1}, {2, 3}, {4, -1}, {5, 0}, {0, 3}} {
+		if _, ok := r.SubtreeSize(q.id, q.level); ok {
+			t.Errorf("expected no size for id=%d level=%d", q.id, q.level)
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	r := New[int, SizedString]()
+	if m := r.Metrics(); m != (RopeMetrics{}) {
+		t.Errorf("expected zero metrics, got: %+v", m)
+	}
+
+	r.Insert(0, 1, "abcd")
+	r.Insert(1, 2, "ab")
+	r.Insert(2, 3, "")
+	r.Insert(3, 4, "ab")
+	r.Delete(1, 3)
+	until, insert := 4, 5
+	r.Splice(0, &until, &insert, "abcdef")
+
+	m := r.Metrics()
+	if m.Inserts != 5 || m.Deletes != 4 {
+		t.Errorf("expected 5 inserts and 4 deletes, got: %+v", m)
+	}
+	if m.AverageLen != 6 {
+		t.Errorf("expected average len=6, got: %v", m.AverageLen)
+	}
+
+	r.Delete(0, 5)
+	if m := r.Metrics(); m.Deletes != 5 || m.AverageLen != 0 {
+		t.Errorf("expected 5 deletes and no average, got: %+v", m)
+	}
+}
+
+func TestFragmentationReport(t *testing.T) {
+	r := New[int, SizedString]()
+	after := 0
+	for _, s := range []SizedString{"a", "b", "c", "long", "d", "", "e", "f", "long", "g", "h"} {
+		id := nextId()
+		r.Insert(after, id, s)
+		after = id
+	}
+
+	got := r.FragmentationReport(2)
+	want := FragReport{Small: 8, Runs: 3, CoalescedCount: 11 - 2 - 1 - 1}
+	if got != want {
+		t.Errorf("expected %+v, got: %+v", want, got)
+	}
+
+	if got := r.FragmentationReport(0); got != (FragReport{CoalescedCount: 11}) {
+		t.Errorf("expected nothing small, got: %+v", got)
+	}
+}
+
+func TestReseedDebugOutput(t *testing.T) {
+	build := func() string {
+		// use the same inserts for both
+		ops := rand.New(rand.NewPCG(1, 2))
+
+		r := New[int, SizedString]()
+		r.Reseed(5678)
+		for i := 1; i <= 200; i++ {
+			r.Insert(ops.IntN(i), i, SizedString("abc"[:ops.IntN(4)]))
+		}
+		r.Delete(10, 40)
+
+		var buf bytes.Buffer
+		r.DebugFprint(&buf)
+		return buf.String()
+	}
+
+	first := build()
+	second := build()
+	if first == "" || first != second {
+		t.Errorf("expected identical debug output for same seed and inserts")
+	}
+}
+
+func TestDebugBroken(t *testing.T) {
+	r := buildIdRope(10)
+	impl := r.(*ropeImpl[int, SizedString])
+
+	render := func() string {
+		var buf bytes.Buffer
+		r.DebugFprint(&buf)
+		return buf.String()
+	}
+
+	impl.byId[3].levels[0].prev = nil
+	if out := render(); !strings.Contains(out, "!") {
+		t.Errorf("expected marker for nil prev, got:\n%s", out)
+	}
+
+	// as if partially built, with nodes not yet in byId
+	delete(impl.byId, 9)
+	if out := render(); !strings.Contains(out, "! more entries than Count=9") {
+		t.Errorf("expected marker for too many entries, got:\n%s", out)
+	}
+
+	impl.byId[5].levels = nil
+	if out := render(); !strings.Contains(out, "! id=5 has no levels") {
+		t.Errorf("expected marker for missing levels, got:\n%s", out)
+	}
+}
+
+// countingSource counts calls to an underlying rand.Source.
+type countingSource struct {
+	rand.Source
+	calls int
+}
+
+func (c *countingSource) Uint64() uint64 {
+	c.calls++
+	return c.Source.Uint64()
+}
+
+func TestRandomHeight(t *testing.T) {
+	const count = 1_000_000
+	src := &countingSource{Source: rand.NewPCG(1, 2)}
+	r := newRope(0, SizedString(""), maxHeight)
+	r.rng = rand.New(src)
+
+	hist := make([]int, maxHeight)
+	for range count {
+		hist[r.randomHeight()-1]++
+	}
+
+	if src.calls > count/16 {
+		t.Errorf("expected many heights per draw, got calls=%d for count=%d", src.calls, count)
+	}
+	if ratio := float64(hist[0]) / count; math.Abs(ratio-0.5) > 0.01 {
+		t.Errorf("bad ratio at height=1: %v", ratio)
+	}
+	for i := 1; i < len(hist) && hist[i-1] >= 10_000; i++ {
+		ratio := float64(hist[i]) / float64(hist[i-1])
+		if math.Abs(ratio-0.5) > 0.05 {
+			t.Errorf("bad ratio at height=%d: %v (hist=%v)", i+1, ratio, hist)
+		}
+	}
+
+	// limits are respected
+	r = newRope(0, SizedString(""), 3)
+	for range 1000 {
+		if h := r.randomHeight(); h < 1 || h > 3 {
+			t.Fatalf("bad height: %d", h)
+		}
+	}
+}
+
+func BenchmarkRandomHeight(b *testing.B) {
+	r := newRope(0, SizedString(""), maxHeight)
+	for b.Loop() {
+		r.randomHeight()
+	}
+}
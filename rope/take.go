@@ -0,0 +1,85 @@
+package rope
+
+import (
+	"slices"
+)
+
+func (r *ropeImpl[Id, T]) TakePrefix(length int) (Rope[Id, T], error) {
+	if length < 0 || length > r.len {
+		return nil, ErrBadRange
+	}
+
+	var entries []Info[Id, T]
+	var pos int
+	for node := r.head.levels[0].next; node != nil && pos < length; node = node.levels[0].next {
+		dl := node.dl
+		if over := pos + dl.Len - length; over > 0 {
+			s, ok := any(dl.Data).(Slicer[T])
+			if !ok {
+				return nil, ErrNotSlicer
+			}
+			dl = DataLen[T]{Len: dl.Len - over, Data: s.Slice(0, dl.Len-over)}
+		}
+		entries = append(entries, Info[Id, T]{Id: node.id, DataLen: dl})
+		pos += node.dl.Len
+	}
+
+	out, err := r.buildLike(entries)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *ropeImpl[Id, T]) TakeSuffix(length int) (Rope[Id, T], error) {
+	if length < 0 || length > r.len {
+		return nil, ErrBadRange
+	}
+
+	// walk back from the end, then reverse into order
+	var entries []Info[Id, T]
+	var pos int
+	for node := r.tail; node != &r.head && pos < length; node = node.levels[0].prev {
+		dl := node.dl
+		if over := pos + dl.Len - length; over > 0 {
+			s, ok := any(dl.Data).(Slicer[T])
+			if !ok {
+				return nil, ErrNotSlicer
+			}
+			dl = DataLen[T]{Len: dl.Len - over, Data: s.Slice(over, dl.Len)}
+		}
+		entries = append(entries, Info[Id, T]{Id: node.id, DataLen: dl})
+		pos += node.dl.Len
+	}
+	slices.Reverse(entries)
+
+	out, err := r.buildLike(entries)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *ropeImpl[Id, T]) Shard(n int) []Rope[Id, T] {
+	if n < 1 {
+		return nil
+	}
+
+	// each shard ends with the entry containing its share of the length, as ByPosition without biasAfter
+	parts := make([][]Info[Id, T], n)
+	var pos, i int
+	for node := r.head.levels[0].next; node != nil; node = node.levels[0].next {
+		parts[i] = append(parts[i], Info[Id, T]{Id: node.id, DataLen: node.dl})
+		pos += node.dl.Len
+		for i < n-1 && pos > 0 && pos >= (i+1)*r.len/n {
+			i++
+		}
+	}
+
+	out := make([]Rope[Id, T], n)
+	for i, entries := range parts {
+		// these entries came from this rope, so can't fail
+		out[i], _ = r.buildLike(entries)
+	}
+	return out
+}
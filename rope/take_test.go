@@ -0,0 +1,142 @@
+package rope
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func buildTakeRope() Rope[int, SizedString] {
+	r := New[int, SizedString]()
+	r.Insert(0, 1, "hello")
+	r.Insert(1, 2, "")
+	r.Insert(2, 3, " there")
+	r.Insert(3, 4, "!")
+	return r
+}
+
+func TestTakePrefix(t *testing.T) {
+	r := buildTakeRope()
+
+	// mid-node
+	p, err := r.TakePrefix(8)
+	if err != nil {
+		t.Fatalf("couldn't take prefix: %v", err)
+	}
+	if got := materialize(p); got != "hello th" {
+		t.Errorf("expected prefix, got: %q", got)
+	}
+	if p.LastId() != 3 || p.Count() != 3 || p.Len() != 8 {
+		t.Errorf("bad prefix: lastId=%d count=%d len=%d", p.LastId(), p.Count(), p.Len())
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+
+	// on a boundary, which excludes the zero-length entry there
+	p, _ = r.TakePrefix(5)
+	if got := materialize(p); got != "hello" || p.Count() != 1 {
+		t.Errorf("expected prefix of one entry, got: %q count=%d", got, p.Count())
+	}
+
+	// the original is unchanged
+	if got := materialize(r); got != "hello there!" || r.Count() != 4 {
+		t.Errorf("expected original unchanged, got: %q", got)
+	}
+
+	if _, err := r.TakePrefix(100); err != ErrBadRange {
+		t.Errorf("expected ErrBadRange, got: %v", err)
+	}
+	n := NewRoot[int, string]("")
+	n.InsertInfo(0, 1, "abc", 3)
+	if _, err := n.TakePrefix(1); err != ErrNotSlicer {
+		t.Errorf("expected ErrNotSlicer, got: %v", err)
+	}
+}
+
+func TestTakeSuffix(t *testing.T) {
+	r := buildTakeRope()
+	r.Insert(4, 5, "")
+
+	// crossing entries
+	s, err := r.TakeSuffix(4)
+	if err != nil {
+		t.Fatalf("couldn't take suffix: %v", err)
+	}
+	if got := materialize(s); got != "ere!" {
+		t.Errorf("expected suffix, got: %q", got)
+	}
+	if s.Count() != 3 || s.LastId() != 5 || s.Find(3) != 3 {
+		t.Errorf("bad suffix: count=%d lastId=%d", s.Count(), s.LastId())
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("invalid: %v", err)
+	}
+
+	// on a boundary, which excludes the zero-length entry there
+	s, _ = r.TakeSuffix(7)
+	if got := materialize(s); got != " there!" || s.Count() != 3 {
+		t.Errorf("expected suffix of three entries, got: %q count=%d", got, s.Count())
+	}
+	if _, ok := s.AnchorOf(2); ok {
+		t.Errorf("expected zero-length entry at start to be excluded")
+	}
+
+	if got := materialize(r); got != "hello there!" || r.Count() != 5 {
+		t.Errorf("expected original unchanged, got: %q", got)
+	}
+	if _, err := r.TakeSuffix(-1); err != ErrBadRange {
+		t.Errorf("expected ErrBadRange, got: %v", err)
+	}
+}
+
+func TestShard(t *testing.T) {
+	entries := make([]Info[int, SizedString], 0, 100_000)
+	var length int
+	for i := range cap(entries) {
+		s := SizedString("abcdefghijklmnopqrst"[:rand.IntN(21)])
+		entries = append(entries, Info[int, SizedString]{Id: i + 1, DataLen: DataLen[SizedString]{Len: len(s), Data: s}})
+		length += len(s)
+	}
+	r, _ := BuildFromSlice(entries)
+
+	shards := r.Shard(4)
+	if len(shards) != 4 {
+		t.Fatalf("expected 4 shards, got: %d", len(shards))
+	}
+	for i, s := range shards {
+		if err := s.Validate(); err != nil {
+			t.Fatalf("invalid shard %d: %v", i, err)
+		}
+		// boundary entries stay whole, so shards are off by less than an entry
+		if diff := s.Len() - length/4; diff < -20 || diff > 20 {
+			t.Errorf("expected shard %d to have len near %d, got: %d", i, length/4, s.Len())
+		}
+		// positions restart in each shard
+		first := s.Info(0).Next
+		if got := s.Find(first); got != s.Info(first).Len {
+			t.Errorf("expected shard %d positions to restart, got: %d", i, got)
+		}
+	}
+	if r.Len() != length || r.Count() != len(entries) {
+		t.Errorf("expected original unchanged")
+	}
+
+	joined := shards[0]
+	for _, s := range shards[1:] {
+		if err := joined.Concat(s); err != nil {
+			t.Fatalf("couldn't concat: %v", err)
+		}
+	}
+	if !joined.SameOrder(r) || joined.Len() != r.Len() {
+		t.Errorf("expected shards to reassemble to the original")
+	}
+	for id, dl := range r.Iter(0) {
+		if got := joined.Info(id).DataLen; got != dl {
+			t.Fatalf("expected same data for id=%d, got: %+v vs %+v", id, got, dl)
+		}
+	}
+
+	if got := New[int, SizedString]().Shard(3); len(got) != 3 || got[0].Count() != 0 {
+		t.Errorf("expected empty shards of an empty rope")
+	}
+}
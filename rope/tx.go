@@ -0,0 +1,82 @@
+package rope
+
+func (r *ropeImpl[Id, T]) Transaction(fn func(tx Rope[Id, T]) error) error {
+	mark := len(r.journal)
+
+	r.txDepth++
+	done := false
+	defer func() {
+		if !done {
+			// fn panicked, so undo its changes before the panic continues
+			r.txDepth--
+			r.rollback(mark)
+			if r.txDepth == 0 {
+				r.journal = nil
+			}
+		}
+	}()
+	err := fn(r)
+	done = true
+	r.txDepth--
+
+	if err != nil {
+		r.rollback(mark)
+	}
+	if r.txDepth == 0 {
+		r.journal = nil
+	}
+	return err
+}
+
+// record adds an undo step. Only call this if a transaction is in progress.
+func (r *ropeImpl[Id, T]) record(undo func()) {
+	r.journal = append(r.journal, undo)
+}
+
+// rollback runs undo steps in reverse until only mark remain.
+func (r *ropeImpl[Id, T]) rollback(mark int) {
+	depth := r.txDepth
+	r.txDepth = 0 // don't record the undo itself
+
+	for i := len(r.journal) - 1; i >= mark; i-- {
+		r.journal[i]()
+		r.journal[i] = nil
+	}
+	r.journal = r.journal[:mark]
+
+	r.txDepth = depth
+}
+
+func (r *ropeImpl[Id, T]) recordInsert(id Id) {
+	r.record(func() { r.undoInsert(id) })
+}
+
+func (r *ropeImpl[Id, T]) recordDelete(afterId Id, removed []Removed[Id, T]) {
+	r.record(func() { r.undoDelete(afterId, removed) })
+}
+
+func (r *ropeImpl[Id, T]) undoInsert(id Id) {
+	node := r.byId[id]
+	r.splice(node.levels[0].prev, true, id, false, id, 0, *new(T), nil)
+}
+
+func (r *ropeImpl[Id, T]) undoDelete(afterId Id, removed []Removed[Id, T]) {
+	prev := afterId
+	for _, rm := range removed {
+		r.splice(r.byId[prev], false, prev, true, rm.Id, rm.Len, rm.Data, nil)
+		prev = rm.Id
+	}
+}
+
+func (r *ropeImpl[Id, T]) undoConcat(oldLastId Id, o *ropeImpl[Id, T]) {
+	after := r.byId[oldLastId]
+
+	var entries []Info[Id, T]
+	for node := after.levels[0].next; node != nil; node = node.levels[0].next {
+		entries = append(entries, Info[Id, T]{Id: node.id, DataLen: node.dl})
+	}
+	r.splice(after, true, r.tail.id, false, r.tail.id, 0, *new(T), nil)
+
+	o.appendNodes(entries)
+	o.indexAfter(&o.head, len(entries))
+}
@@ -0,0 +1,123 @@
+package rope
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var errAbort = errors.New("abort")
+
+func TestTransaction(t *testing.T) {
+	r := buildIdRope(5)
+	r.SetRoot("root")
+	before := collect(r)
+
+	err := r.Transaction(func(tx Rope[int, SizedString]) error {
+		tx.Insert(0, 100, "hello")
+		tx.Insert(100, 101, "there")
+		tx.Delete(2, 4)
+		tx.ReplaceByPosition(9, 12, 102, "!", 1)
+		tx.SetRoot("other")
+		return errAbort
+	})
+	if err != errAbort {
+		t.Errorf("expected error to be returned, got: %v", err)
+	}
+
+	if err := r.Validate(); err != nil {
+		t.Fatalf("invalid after rollback: %v", err)
+	}
+	if after := collect(r); !reflect.DeepEqual(before, after) {
+		t.Errorf("rope changed after rollback: %+v vs %+v", before, after)
+	}
+	if r.Root() != "root" || r.Len() != 5 {
+		t.Errorf("bad root=%q or len=%d after rollback", r.Root(), r.Len())
+	}
+
+	// successful transactions keep their changes
+	err = r.Transaction(func(tx Rope[int, SizedString]) error {
+		return tx.Insert(5, 6, "x")
+	})
+	if err != nil || r.Count() != 6 {
+		t.Errorf("expected commit: %v count=%d", err, r.Count())
+	}
+}
+
+func TestTransactionNested(t *testing.T) {
+	r := buildIdRope(3)
+
+	r.Transaction(func(tx Rope[int, SizedString]) error {
+		tx.Insert(3, 4, "x")
+
+		inner := tx.Transaction(func(tx Rope[int, SizedString]) error {
+			tx.Delete(0, 2)
+			return errAbort
+		})
+		if inner != errAbort || tx.Count() != 4 {
+			t.Errorf("inner should roll back only itself: %v count=%d", inner, tx.Count())
+		}
+
+		tx.Transaction(func(tx Rope[int, SizedString]) error {
+			return tx.Insert(4, 5, "y")
+		})
+		return errAbort
+	})
+
+	if err := r.Validate(); err != nil {
+		t.Fatalf("invalid after rollback: %v", err)
+	}
+	if r.Count() != 3 || r.LastId() != 3 {
+		t.Errorf("outer should roll back everything: count=%d", r.Count())
+	}
+}
+
+func TestTransactionPanic(t *testing.T) {
+	r := buildIdRope(5)
+	before := collect(r)
+
+	func() {
+		defer func() {
+			if p := recover(); p != errAbort {
+				t.Errorf("expected panic to continue, got: %v", p)
+			}
+		}()
+		r.Transaction(func(tx Rope[int, SizedString]) error {
+			tx.Insert(0, 100, "hello")
+			tx.Delete(2, 4)
+			panic(errAbort)
+		})
+	}()
+
+	if err := r.Validate(); err != nil {
+		t.Fatalf("invalid after panic: %v", err)
+	}
+	if after := collect(r); !reflect.DeepEqual(before, after) {
+		t.Errorf("rope changed after panic: %+v vs %+v", before, after)
+	}
+
+	// later changes are not part of any transaction
+	impl := r.(*ropeImpl[int, SizedString])
+	r.Insert(5, 6, "x")
+	if impl.txDepth != 0 || len(impl.journal) != 0 {
+		t.Errorf("expected transaction to be finished: depth=%d journal=%d", impl.txDepth, len(impl.journal))
+	}
+}
+
+func TestTransactionConcat(t *testing.T) {
+	r := buildIdRope(3)
+	other, _ := BuildFromSlice(randomEntries(10))
+	otherBefore := collect(other)
+
+	r.Transaction(func(tx Rope[int, SizedString]) error {
+		tx.Concat(other)
+		return errAbort
+	})
+
+	if r.Count() != 3 || r.Validate() != nil {
+		t.Errorf("bad rope after concat rollback")
+	}
+	if !reflect.DeepEqual(otherBefore, collect(other)) || other.Validate() != nil {
+		t.Errorf("other should be restored after concat rollback")
+	}
+}
@@ -3,7 +3,12 @@
 package rope
 
 import (
+	"context"
+	"io"
 	"iter"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
 )
 
 // Info is a holder for info looked up in a Rope.
@@ -18,15 +23,54 @@ type DataLen[T any] struct {
 	Data T
 }
 
+// NewDataLen returns a DataLen, or ErrNegativeLength if length is negative.
+func NewDataLen[T any](data T, length int) (DataLen[T], error) {
+	if length < 0 {
+		return DataLen[T]{}, ErrNegativeLength
+	}
+	return DataLen[T]{Len: length, Data: data}, nil
+}
+
+// NewInfo returns an Info for use with BuildFromSlice and friends, or ErrNegativeLength if length is negative.
+// Next and Prev are left as the zero Id.
+func NewInfo[Id comparable, T any](id Id, data T, length int) (Info[Id, T], error) {
+	dl, err := NewDataLen(data, length)
+	if err != nil {
+		return Info[Id, T]{}, err
+	}
+	return Info[Id, T]{Id: id, DataLen: dl}, nil
+}
+
 type ropeLevel[Id comparable, T any] struct {
 	next        *ropeNode[Id, T] // can be nil
 	prev        *ropeNode[Id, T] // always set
 	subtreesize int
+	count       int // number of nodes covered, like subtreesize (the head counts as zero)
+}
+
+// measures holds the custom measures of part of a Rope, in the order given to NewWithMeasures.
+// These are kept in nodeMeasures rather than on each ropeLevel, so that a Rope without any pays nothing for them.
+type measures [maxMeasures]int
+
+func (m measures) add(o measures) measures {
+	for i := range m {
+		m[i] += o[i]
+	}
+	return m
+}
+
+func (m measures) sub(o measures) measures {
+	for i := range m {
+		m[i] -= o[i]
+	}
+	return m
 }
 
+// iterRef tracks iterators waiting at a node.
+// The count is atomic so that many readers can iterate concurrently, as long as nothing writes.
 type iterRef[Id comparable, T any] struct {
-	count int
-	node  *ropeNode[Id, T]
+	count atomic.Int32
+	moved *ropeNode[Id, T] // set if the node was removed while iterators were here
 }
 
 type ropeNode[Id comparable, T any] struct {
@@ -34,8 +78,25 @@ type ropeNode[Id comparable, T any] struct {
 	dl     DataLen[T]
 	levels []ropeLevel[Id, T]
 
-	// if set, an iterator is chilling here for the next value
-	iterRef *iterRef[Id, T]
+	// iterators chilling here for the next value
+	iterRef iterRef[Id, T]
+
+	// incremented when this node is removed, to invalidate any Anchor
+	gen int
+}
+
+// Anchor is a handle to an entry in a Rope, used to repeatedly operate there without looking up its Id.
+// It becomes invalid if its entry is removed.
+type Anchor[Id comparable, T any] struct {
+	node *ropeNode[Id, T]
+	gen  int
+}
+
+// ropeSeek is a node found while seeking, with the length and count from its start to the seek target.
+type ropeSeek[Id comparable, T any] struct {
+	node  *ropeNode[Id, T]
+	sub   int
+	count int
 }
 
 type Removed[Id comparable, T any] struct {
@@ -45,31 +106,149 @@ type Removed[Id comparable, T any] struct {
 }
 
 type ropeImpl[Id comparable, T any] struct {
-	head     ropeNode[Id, T]
-	len      int
-	byId     map[Id]*ropeNode[Id, T]
-	height   int // matches len(head.levels)
-	nodePool []*ropeNode[Id, T]
-	lastId   Id
+	head           ropeNode[Id, T]
+	len            int
+	contentCount   int // nodes with non-zero length
+	byId           map[Id]*ropeNode[Id, T]
+	height         int // matches len(head.levels)
+	heightLimit    int // the most levels any node may have, at most limitHeight
+	strictLengths  bool
+	nodePool       []*ropeNode[Id, T]
+	tail           *ropeNode[Id, T] // the last node, or head if empty
+	rng            *rand.Rand       // nil uses the top-level generator
+	seed           *rand.PCG        // the source of rng if set by Reseed, which clones copy
+	heightBits     uint64           // unused random bits for randomHeight
+	heightBitsLeft int
+	lenFn          func(T) int                     // if nil, lengths are zero
+	measureFns     []func(T) int                   // maintained alongside length, at most maxMeasures
+	measure        measures                        // total of measureFns over all nodes
+	nodeMeasures   map[*ropeNode[Id, T]][]measures // custom measures covered by each level of a node, like subtreesize
+	stats          spliceStats
+	inserts        int // entries ever inserted by splice, for Metrics
+	deletes        int // entries ever removed by splice, for Metrics
+	version        int // incremented on every change to structure or length
+	txDepth        int
+	journal        []func() // undo steps for the current transaction
+	markers        []*Marker
+	annotations    []*Annotation
+
+	// buffers for ropes taller than maxHeight, which can't use the stack
+	seekScratch sync.Pool
+	nodeScratch sync.Pool
 }
 
+// RopeMetrics describes the changes made to a Rope over its lifetime, and its current shape.
+type RopeMetrics struct {
+	Inserts    int     // entries inserted via Splice and its wrappers, including by undo
+	Deletes    int     // entries removed via Splice and its wrappers, including by undo
+	AverageLen float64 // Len divided by Count, or zero if empty; lower means more fragmented
+}
+
+// FragReport describes how many short entries a Rope has, to decide whether it's worth coalescing them.
+// Zero-length entries are never counted as short, and end any run, as they usually can't be merged.
+type FragReport struct {
+	Small          int // entries with length shorter than the threshold
+	Runs           int // runs of two or more adjacent small entries
+	CoalescedCount int // Count if every run were merged into a single entry
+}
+
+// SpliceResult is the result of SpliceWithResult.
+// Positions at or after Start before the splice are moved by Delta.
+type SpliceResult[Id comparable, T any] struct {
+	Removed []Removed[Id, T]
+	Start   int // position after the anchor, before the splice
+	Delta   int // length inserted minus length removed
+}
+
+// RemovedAt is a Removed entry with the position it started at before it was removed.
+type RemovedAt[Id comparable, T any] struct {
+	Removed[Id, T]
+	Position int
+}
+
+type spliceStats struct {
+	removedLen, removedCount, insertedLen int
+}
+
+// Gravity determines which Id a position at the boundary between entries resolves to.
+// When the position is inside an entry with length, that entry is always used.
+type Gravity int
+
+const (
+	// GravityNone skips over zero-length entries to the entry with length after the position, as ByPosition with biasAfter.
+	// The offset will be equal to the length of that entry.
+	GravityNone Gravity = iota
+	// GravityLeft stops at the entry with length before the position, before any zero-length entries, as ByPosition without biasAfter.
+	GravityLeft
+	// GravityRight stops at the last entry ending at the position, after any zero-length entries.
+	GravityRight
+)
+
 type Sizer interface {
 	Len() int
 }
 
+// Bytes is a []byte which implements Sizer.
+type Bytes []byte
+
+func (b Bytes) Len() int { return len(b) }
+
+// Runes is a []rune which implements Sizer.
+type Runes []rune
+
+func (r Runes) Len() int { return len(r) }
+
+// Chars is a string which implements Sizer, by its length in bytes.
+type Chars string
+
+func (c Chars) Len() int { return len(c) }
+
+// Slicer can be implemented by data so that a Rope can trim entries that are partially replaced.
+type Slicer[T any] interface {
+	// Slice returns the part of this data between start and end, as measured by its length in the Rope.
+	Slice(start, end int) T
+}
+
+// ReadOnlyRope is the subset of Rope which only queries, for callers which shouldn't make changes.
+// Each method is as documented on Rope.
+type ReadOnlyRope[Id comparable, T any] interface {
+	Len() int
+	Count() int
+	Find(id Id) int
+	Info(id Id) Info[Id, T]
+	ByPosition(position int, biasAfter bool) (id Id, offset int)
+	Compare(a, b Id) (cmp int, ok bool)
+	Less(a, b Id) bool
+	Between(afterA, afterB Id) (distance int, ok bool)
+	Iter(afterId Id) iter.Seq2[Id, DataLen[T]]
+	LastId() Id
+	HeadId() Id
+}
+
 // Rope is a skip list.
 // It supports zero-length entries.
-// It is not goroutine-safe.
+// It is not goroutine-safe, but methods which only read, including Iter, may be called concurrently if nothing writes.
 // The zero Id is always part of the Rope and has zero length, don't use it to add items.
+// (If built with NewWithSentinel, the sentinel Id takes the place of the zero Id.)
 type Rope[Id comparable, T any] interface {
 	DebugPrint()
+	// DebugFprint writes the same output as DebugPrint to w, without any log prefix.
+	DebugFprint(w io.Writer)
 	// Returns the total sum of the parts of the rope. O(1).
 	Len() int
 	// Returns the number of parts here. O(1).
 	Count() int
+	// Returns the number of parts here with non-zero length. O(1).
+	ContentCount() int
+	// Returns whether there are no parts here, other than the zero Id. O(1).
+	IsEmpty() bool
 	// Finds the position after the given Id.
 	// This lookup costs ~O(logn).
 	Find(id Id) int
+	// FindOrNearest is as Find, but if id is not here, finds the position after fallbackPrev instead.
+	// Pass the entry id was last known to follow, so a stale cursor recovers to where id used to be.
+	// Returns -1 if neither is here.
+	FindOrNearest(id Id, fallbackPrev Id) int
 	// Finds info on the given Id.
 	// This lookup costs O(1).
 	Info(id Id) Info[Id, T]
@@ -77,12 +256,63 @@ type Rope[Id comparable, T any] interface {
 	// Returns the offset from the end of the Id.
 	// This costs ~O(logn).
 	// Either stops before or skips after zero-length content based on biasAfter.
-	// e.g., with 0/false, this will always return the zero Id.
+	// e.g., with 0/false, this will always return the zero Id, and with Len()/true, the last Id even if it is zero-length.
 	ByPosition(position int, biasAfter bool) (id Id, offset int)
+	// PositionOf is the inverse of ByPosition, returning the position offset from the end of the Id.
+	// Returns false if the Id is not here or offset is outside [0,len] of that Id.
+	// This costs ~O(logn).
+	PositionOf(id Id, offset int) (int, bool)
+	// IsBoundary returns whether position is between two entries, rather than inside one, as ByPosition returning a zero offset.
+	// The start and end of the Rope are always boundaries, and positions outside it never are.
+	// This costs ~O(logn).
+	IsBoundary(position int) bool
+	// ByPositionG is as ByPosition, but resolves boundaries and stacks of zero-length entries via Gravity.
+	ByPositionG(position int, g Gravity) (id Id, offset int)
+	// ByPositions is as ByPosition for every position, but returns the zero Id for positions outside [0,Len()].
+	// For many positions, this sorts them and finds all in a single walk.
+	// Costs O(min(klogn, n+klogk)), where k is the number of positions.
+	ByPositions(positions []int, biasAfter bool) []struct {
+		Id     Id
+		Offset int
+	}
+	// ByMeasure is as ByPosition without biasAfter, but searches a custom measure given to NewWithMeasures.
+	// Returns the offset in that measure from the end of the Id.
+	// This costs ~O(logn).
+	ByMeasure(measureIndex, value int) (id Id, offset int)
+	// PrefixMeasure is as Find, but returns the total of a custom measure up to and including the given Id.
+	// Returns -1 if the Id is not here.
+	// This costs ~O(logn).
+	PrefixMeasure(id Id, measureIndex int) int
+	// ByWeight is as ByMeasure for the weight given to NewWeighted, which is the first custom measure.
+	ByWeight(w int) (id Id, offset int)
+	// PrefixWeight is as PrefixMeasure for the weight given to NewWeighted, which is the first custom measure.
+	PrefixWeight(id Id) int
+	// TotalWeight returns the total weight of all entries, or zero if the Rope has no custom measures. O(1).
+	TotalWeight() int
+	// SampleByLength picks an entry at random, weighted by its length, as if picking a random position.
+	// Returns the offset from the end of the Id to that position, as ByPosition with biasAfter.
+	// The rng may be nil to use the top-level generator. Returns the zero Id if Len is zero.
+	// This costs ~O(logn).
+	SampleByLength(rng *rand.Rand) (id Id, offset int)
+	// SampleNode picks an entry uniformly at random, regardless of its length, or returns false if the Rope is empty.
+	// The rng may be nil to use the top-level generator.
+	// This costs ~O(logn).
+	SampleNode(rng *rand.Rand) (id Id, ok bool)
+	// CaretAt returns the Id a new entry should be inserted after so that it starts at position.
+	// If offset is zero, inserting after anchorId places content exactly at position, after any zero-length entries there.
+	// This is the case for position zero (the zero Id or a zero-length entry) and the end of the Rope (LastId).
+	// Otherwise, position is inside anchorId, offset from its end, and the entry must be split to insert there.
+	// Positions outside the Rope are clamped.
+	CaretAt(position int) (anchorId Id, offset int)
 	// Between returns the distance between _after_ these two nodes.
 	// This costs ~O(logn), and is more expensive than Compare.
 	Between(afterA, afterB Id) (distance int, ok bool)
+	// CountBetween counts the entries strictly between afterA and afterB, so it is zero if they are adjacent or the same.
+	// This is negative if afterB is before afterA.
+	// This costs ~O(logn), via RankLogN.
+	CountBetween(afterA, afterB Id) (count int, ok bool)
 	// Compare the position of the two Id in this Rope.
+	// This is a strict total order over present Ids: cmp is only zero when a == b, including for zero-length entries.
 	// Costs ~O(logn).
 	Compare(a, b Id) (cmp int, ok bool)
 	// Less determines if the first Id in this Rope before the other. For sorting.
@@ -91,17 +321,294 @@ type Rope[Id comparable, T any] interface {
 	// Iter reads from after the given Id.
 	// It is safe to use even if the Rope is modified.
 	Iter(afterId Id) iter.Seq2[Id, DataLen[T]]
+	// IterUntil reads from after the given Id, up to and including untilId.
+	// It yields nothing if untilId is not after afterId.
+	// It is safe to use even if the Rope is modified, but stops early if untilId is removed.
+	IterUntil(afterId, untilId Id) iter.Seq2[Id, DataLen[T]]
+	// IterFilter is as Iter, but only yields entries for which keep returns true.
+	IterFilter(afterId Id, keep func(Id, DataLen[T]) bool) iter.Seq2[Id, DataLen[T]]
+	// IterCtx is as Iter, but stops early once ctx is done.
+	// The ctx is checked before the first entry and then every 1024 entries, so a few more may be yielded after it is cancelled.
+	IterCtx(ctx context.Context, afterId Id) iter.Seq2[Id, DataLen[T]]
+	// IterFromPosition is as Iter, but starts from the entry containing position, found as ByPosition.
+	// At a boundary, biasAfter skips zero-length entries there, and otherwise they are included.
+	IterFromPosition(position int, biasAfter bool) iter.Seq2[Id, DataLen[T]]
+	// IterMeasure reads from after the given Id, yielding each Id with the total of a custom measure up to and including it, as PrefixMeasure.
+	// It is safe to use even if the Rope is modified.
+	// Yields nothing if afterId is not here, or measureIndex is not one of this Rope's measures.
+	IterMeasure(afterId Id, measureIndex int) iter.Seq2[Id, int]
+	// IterContext is as Iter, but calls fn with each entry and the entries either side of it.
+	// Prev and next are the zero Info before the first entry and after the last.
+	// All three are read just before each call, so fn may change the Rope, and later calls see those changes.
+	IterContext(afterId Id, fn func(prev, curr, next Info[Id, T]) bool)
+	// IterCoalesced is as Iter, but combines runs of adjacent entries for which shouldMerge returns true.
+	// Both funcs are passed the run so far and the next entry; the Id yielded is that of the first entry in each run.
+	// This does not change the Rope.
+	IterCoalesced(afterId Id, shouldMerge func(a, b DataLen[T]) bool, merge func(a, b DataLen[T]) DataLen[T]) iter.Seq2[Id, DataLen[T]]
+	// IterPosReverse reads backwards from before the given Id, yielding the start position of each entry.
+	// It is safe to use even if the Rope is modified.
+	IterPosReverse(beforeId Id) iter.Seq2[int, DataLen[T]]
+	// Iterator is as Iter, but returns a stateful Iterator which can be inspected between steps.
+	// Call Close if it is abandoned before Next returns false.
+	Iterator(afterId Id) *Iterator[Id, T]
+	// IterReverse reads backwards from the last entry, which is found in O(1).
+	// It is safe to use even if the Rope is modified.
+	IterReverse() iter.Seq2[Id, DataLen[T]]
 	// Splice performs insert, delete, or replace operations.
 	// afterId: anchor point (nil = head/start)
 	// deleteUntilId: if non-nil, delete nodes from afterId until this Id
 	// newId: if non-nil, insert new node with given data
+	// If both are given, the delete happens first, and the new node is inserted directly after afterId (which always survives).
+	// The new node therefore takes the place of the deleted range.
+	// The length of the new node is from the Rope's length func if it has one, otherwise from Sizer, otherwise zero.
 	// Returns removed nodes for undo support.
+	// Fails with ErrUntilNotAfter if deleteUntilId is not present after afterId.
 	// Costs ~O(logn+m), where m is the number of nodes being deleted.
 	Splice(afterId Id, deleteUntilId *Id, insertId *Id, data T) (removed []Removed[Id, T], err error)
+	// SpliceInto is as Splice, but appends removed entries to buf after resetting its length, so it can be reused between calls.
+	// The buf may be nil.
+	SpliceInto(buf []Removed[Id, T], afterId Id, deleteUntilId *Id, insertId *Id, data T) ([]Removed[Id, T], error)
+	// SpliceWithFallback is as Splice, but anchors at fallbackId if afterId is not here.
+	// Pass the entry afterId was last known to follow, so a retry after afterId was deleted still succeeds.
+	SpliceWithFallback(afterId, fallbackId Id, deleteUntilId *Id, insertId *Id, data T) (removed []Removed[Id, T], err error)
+	// SpliceWithResult is as Splice, but also returns where the change happened and how much it changed the length.
+	// This lets callers shift an external index of positions without looking them up again.
+	SpliceWithResult(afterId Id, deleteUntilId *Id, insertId *Id, data T) (SpliceResult[Id, T], error)
+	// SpliceAudit is as Splice, but also returns the position each removed entry started at, before anything was removed.
+	SpliceAudit(afterId Id, deleteUntilId *Id, insertId *Id, data T) ([]RemovedAt[Id, T], error)
 	// Insert adds a new entry after afterId. Convenience wrapper around Splice.
 	Insert(afterId Id, newId Id, data T) error
+	// InsertInfo adds a new entry with an explicit length after afterId, returning its Info.
+	InsertInfo(afterId Id, newId Id, data T, length int) (Info[Id, T], error)
+	// CompareAndSplice is as InsertInfo, but only inserts if afterId is currently followed by expectedNextId, else returns ErrConflict.
+	// As for Info, the zero Id is expected after the last entry.
+	CompareAndSplice(afterId Id, expectedNextId Id, insertId Id, data T, length int) error
+	// InsertRun adds entries with explicit lengths in order after afterId, each after the one before it.
+	// Each insert starts from the node just added, rather than looking up its anchor.
+	// If any Id already exists or any length is negative, entries added so far are removed and the error is returned.
+	InsertRun(afterId Id, entries []struct {
+		Id   Id
+		Data T
+		Len  int
+	}) error
+	// InsertOrReplace adds a new entry after afterId, or updates the entry if it is already here.
+	// If it is already directly after afterId, its data and length are updated in-place.
+	// Otherwise, it is moved to be after afterId, which is the same as deleting and inserting it again, so Anchors to it become invalid.
+	// This allows the same insert to be replayed with the same result.
+	// Fails with ErrBadAnchor if afterId is not here or is the same as id.
+	InsertOrReplace(afterId Id, id Id, data T, length int) error
 	// Delete removes entries from after afterId until untilId. Convenience wrapper around Splice.
+	// This allocates the returned slice of removed entries.
 	Delete(afterId Id, untilId Id) ([]Removed[Id, T], error)
+	// DeleteCount is as Delete, but only returns the number of removed entries.
+	// This does not allocate.
+	DeleteCount(afterId Id, untilId Id) (int, error)
+	// PopFirst removes and returns the first entry after the zero Id, or false if the Rope is empty.
+	// Costs ~O(logn).
+	PopFirst() (Removed[Id, T], bool)
+	// PopLast removes and returns the last entry, or false if the Rope is empty.
+	// Costs ~O(logn).
+	PopLast() (Removed[Id, T], bool)
+	// PopFirstE is as PopFirst, but returns ErrEmpty if the Rope is empty.
+	PopFirstE() (Removed[Id, T], error)
+	// PopLastE is as PopLast, but returns ErrEmpty if the Rope is empty.
+	PopLastE() (Removed[Id, T], error)
+	// DeletePreview returns what Delete would remove from after afterId until untilId, without changing the Rope.
+	// Costs ~O(logn), regardless of how much would be removed.
+	DeletePreview(afterId, untilId Id) (count, totalLen int, err error)
+	// TouchRange checks that the range from after afterId until untilId is valid to Delete, returning the number of entries in it.
+	// As for Splice, the same Id for both is a valid, empty range. This is as DeletePreview, without the length.
+	TouchRange(afterId, untilId Id) (count int, err error)
+	// DeleteRanges is as Delete for each afterId/untilId pair, which may be given in any order.
+	// The removed entries are always returned in position order, regardless of the order of ranges.
+	// All ranges are checked before anything is removed, returning ErrRangesOverlap if any overlap.
+	// Costs ~O(klogk·logn+m), where k is the number of ranges and m the number of entries removed.
+	DeleteRanges(ranges [][2]Id) ([]Removed[Id, T], error)
+	// DeleteWhere removes every entry for which pred returns true, in a single walk, returning them in order.
+	// Each run of adjacent matches is removed at once, so this costs ~O(n+klogn) for k runs.
+	// The pred must not change the Rope. As for Delete, iterators parked at a removed entry continue from the entry before it.
+	DeleteWhere(pred func(Id, DataLen[T]) bool) ([]Removed[Id, T], error)
+	// DeleteStream is as Delete, but passes each removed entry to fn as it is removed, rather than allocating.
+	// Returning false from fn stops further calls, but cannot stop the delete.
+	DeleteStream(afterId Id, untilId Id, fn func(Removed[Id, T]) bool) error
 	// LastId returns the last Id in this rope.
 	LastId() Id
+	// ReadOnly returns this Rope as a ReadOnlyRope.
+	// It is the same Rope, so it sees any later changes.
+	ReadOnly() ReadOnlyRope[Id, T]
+	// FirstData returns the data of the first entry after the zero Id, or false if the Rope is empty. O(1).
+	FirstData() (DataLen[T], bool)
+	// LastData returns the data of the last entry, or false if the Rope is empty. O(1).
+	LastData() (DataLen[T], bool)
+	// HeadId returns the zero Id (or sentinel) at the head of this Rope, so that Iter from it reads every entry. O(1).
+	HeadId() Id
+	// Transaction runs fn, passing this Rope as tx.
+	// If fn returns an error, every change made to the Rope within fn is undone, and the error is returned.
+	// This includes adding or removing markers and annotations. If fn panics, its changes are undone before the panic continues.
+	// Entries restored by undo have the same Id and data, but any Anchor to them is invalid.
+	// Transactions may be nested.
+	Transaction(fn func(tx Rope[Id, T]) error) error
+	// IsHead returns whether the given Id is the zero Id (or sentinel) at the head of this Rope.
+	IsHead(id Id) bool
+	// Root returns the data of the zero Id, as set by NewRoot or SetRoot.
+	Root() T
+	// SetRoot updates the data of the zero Id. It always has zero length.
+	SetRoot(root T)
+	// AnchorOf returns an Anchor for the given Id, or false if it is not here.
+	AnchorOf(id Id) (Anchor[Id, T], bool)
+	// SpliceAt is as Splice, but relative to an Anchor from this Rope rather than an Id.
+	// Fails with ErrBadAnchor if the Anchor's entry was removed, or the Anchor is from another Rope.
+	SpliceAt(a Anchor[Id, T], deleteUntilId *Id, insertId *Id, data T) (removed []Removed[Id, T], err error)
+	// InsertAt adds a new entry after the Anchor. Convenience wrapper around SpliceAt.
+	InsertAt(a Anchor[Id, T], newId Id, data T) error
+	// Concat moves all entries of other to the end of this Rope, leaving other empty.
+	// Fails with ErrIdExists (and changes nothing) if any Id is in both.
+	// The root value of other is not kept.
+	// Fails with ErrForeignRope if other is not from this package, such as a wrapper, as its entries can't be moved.
+	// Entries of other are measured by this Rope's custom measures, if any, as given to NewWithMeasures.
+	// If other has entries taller than this Rope allows, e.g. when this was made by NewSmall, this Rope's height cap grows to fit them.
+	// Costs ~O(m), where m is the number of entries in other.
+	Concat(other Rope[Id, T]) error
+	// MergeOrdered returns a new Rope containing the entries of this and other, interleaved by less, like merging two sorted lists.
+	// Each input should already be ordered by less; where neither Id is less, the entry from this Rope comes first.
+	// Neither input is changed, and the new Rope keeps the root value of this Rope.
+	// Fails with ErrIdExists if any Id is in both.
+	// Costs O(n+m).
+	MergeOrdered(other Rope[Id, T], less func(a, b Id) bool) (Rope[Id, T], error)
+	// HeightHistogram returns the number of nodes with each height, where index i counts nodes with i+1 levels.
+	// The zero Id is not counted.
+	// Costs O(n).
+	HeightHistogram() []int
+	// BalanceFactor returns the average number of nodes traversed by Find, relative to the ideal log2(Count()).
+	// A well-formed rope should be near 1; values well above this indicate a degenerate structure.
+	// Returns 1 for ropes with fewer than two nodes.
+	// Costs O(n).
+	BalanceFactor() float64
+	// CountWhere returns the number of entries for which pred returns true.
+	// The zero Id is not counted.
+	// Costs O(n).
+	CountWhere(pred func(DataLen[T]) bool) int
+	// LevelWalk calls fn for each node linked at the given level, starting with the zero Id, until fn returns false.
+	// The subtreesize is the length of that node plus all nodes before the next at this level, so these sum to Len().
+	// This is for diagnostics; it calls fn for nothing if the level is not in use.
+	// Costs O(k), where k is the number of nodes at this level.
+	LevelWalk(level int, fn func(id Id, subtreesize int) bool)
+	// SubtreeSize returns the length of the given entry plus all entries before the next at this level.
+	// Returns false if the entry is not here or is not this tall.
+	// This is for checking custom aggregates against the built-in lengths.
+	SubtreeSize(id Id, level int) (int, bool)
+	// StrictLengths makes every call given an explicit length fail with ErrLengthMismatch if it differs from the length of the data.
+	// This only applies if the Rope measures data, via Sizer or a length func. It is for catching bookkeeping bugs.
+	StrictLengths(strict bool)
+	// Reseed makes this Rope pick node heights from a generator with the given seed.
+	// The same seed and sequence of operations gives the same structure.
+	Reseed(seed uint64)
+	// Grow prepares this Rope to hold about expectedCount entries, by adding the head levels it is expected to need up front.
+	// This is capped by the height limit. It doesn't change what any query returns.
+	// Costs ~O(logn).
+	Grow(expectedCount int)
+	// Rebalance relinks every entry in place with freshly picked heights, as if it was built again via BuildFromSlice.
+	// This undoes any drift from ideal heights, e.g. after a poor generator. Anchors and iterators stay valid.
+	// Costs O(n).
+	Rebalance()
+	// Clone returns a new Rope with the same settings and entries, which can be changed independently of this one.
+	// Data is copied by value, so pointers and slices are shared: use CloneWith to copy them.
+	// Settings include StrictLengths, and after Reseed, a copy of the generator in its current state.
+	// Costs O(n).
+	Clone() Rope[Id, T]
+	// CloneWith is as Clone, but passes each entry's data (and the zero Id's) through copyData.
+	CloneWith(copyData func(T) T) Rope[Id, T]
+	// TakePrefix returns a new Rope with the same settings, containing copies of the entries covering the first length.
+	// An entry crossing length keeps its Id, but is trimmed via Slicer, which T must implement.
+	// Zero-length entries at length are not included. This Rope is unchanged.
+	// Costs O(k), where k is the number of entries taken.
+	TakePrefix(length int) (Rope[Id, T], error)
+	// TakeSuffix is as TakePrefix, but for the entries covering the last length.
+	// Zero-length entries at the end are included, but not those where the suffix starts.
+	TakeSuffix(length int) (Rope[Id, T], error)
+	// Shard returns n new Ropes with the same settings, containing copies of all entries split into runs of about equal length.
+	// Entries are never split, so the shards are only roughly equal, and some may be empty if entries are long.
+	// This does not change this Rope. Costs O(n).
+	Shard(n int) []Rope[Id, T]
+	// ReplaceByPosition replaces the content between startPos and endPos with a single new entry.
+	// Entries wholly inside the range are removed and returned.
+	// An entry only partially inside the range keeps its Id, but is trimmed via Slicer, which T must implement.
+	// The new entry is placed after any zero-length entries at startPos, and these are kept.
+	// Zero-length entries at endPos are also kept.
+	// Fails with ErrWithinNode if the range is strictly within one entry, as this cannot keep all Ids.
+	// Costs ~O(logn+m), where m is the number of entries being removed.
+	ReplaceByPosition(startPos, endPos int, newId Id, data T, newLen int) ([]Removed[Id, T], error)
+	// ReplaceData replaces the data and length of every entry for which match returns true, keeping its Id.
+	// Returns the number of entries changed.
+	// Fails with ErrNegativeLength if replace gives a negative length (or ErrLengthMismatch, with StrictLengths); entries before that one stay changed.
+	// Costs O(n), but is cheaper than changing each entry in turn.
+	ReplaceData(match func(DataLen[T]) bool, replace func(T) (T, int)) (int, error)
+	// ReplaceKeepingId replaces the data and length of the given entry in-place.
+	// Its Id is kept, so references to it remain valid, but offsets within it may now be past its start.
+	// As for ByPosition, an offset counts back from the end of the entry, so zero is its end.
+	// remapOffset maps an old offset to its new one: callers holding (Id, offset) pairs elsewhere should migrate them with it, clamped to [0,newLen].
+	// Markers and annotations after the entry's start and up to its end are moved in the same way.
+	// If remapOffset is nil, these move as for any other change in length.
+	// Fails with ErrBadAnchor for the zero Id or an unknown Id.
+	// Costs ~O(logn).
+	ReplaceKeepingId(id Id, data T, newLen int, remapOffset func(old int) int) error
+	// RankLogN returns the number of entries up to and including the given Id, or -1 if it is not here.
+	// The zero Id has rank zero.
+	// Costs ~O(logn).
+	RankLogN(id Id) int
+	// SelectLogN returns the Id with the given rank, the inverse of RankLogN.
+	// Returns false if n is not in the range [0,Count()].
+	// Costs ~O(logn).
+	SelectLogN(n int) (id Id, ok bool)
+	// PositionMap returns the position after every Id, as Find would, including the zero Id at zero.
+	// Costs O(n).
+	PositionMap() map[Id]int
+	// Finds is as Find for each of the given Ids, omitting those not here.
+	// For many Ids, this finds all in a single walk.
+	// Costs O(min(klogn, n+k)), where k is the number of Ids.
+	Finds(ids []Id) map[Id]int
+	// Validate checks the internal structure of this Rope, returning an error describing the first problem found.
+	// This is for tests and debugging.
+	// Costs O(nlogn).
+	Validate() error
+	// Reachable returns whether the given Id is found by walking every entry from the start, rather than by lookup.
+	// This is for debugging, to find entries which are known but unlinked.
+	// Costs O(n).
+	Reachable(id Id) bool
+	// LastSpliceStats returns the effect of the most recent call which inserted or removed entries.
+	LastSpliceStats() (removedLen, removedCount, insertedLen int)
+	// Metrics returns cumulative counts of inserts and deletes, and the average entry length.
+	// This costs O(1), as counters are kept as entries change.
+	Metrics() RopeMetrics
+	// PrewarmPool fills the pool of spare nodes with up to n new ones, so that the first inserts needn't allocate them.
+	// The pool holds at most a small fixed number of nodes.
+	PrewarmPool(n int)
+	// FragmentationReport counts entries shorter than threshold, and the runs of them which could be merged, e.g. via IterCoalesced.
+	// This costs O(n).
+	FragmentationReport(threshold int) FragReport
+	// AddMarker adds a Marker at the given position, which is clamped to [0,Len()].
+	// The Marker moves as content before it is inserted or removed, but not when content is inserted exactly at its position.
+	// If the content around a Marker is removed, it moves to the start of the removed range.
+	// Every change to this Rope costs an extra O(k) while it has k markers.
+	AddMarker(pos int) *Marker
+	// MarkersInRange returns the markers with positions in [startPos,endPos], inclusive, ordered by position.
+	// Costs O(k+rlogr), where k is the number of markers, and r is the number in range.
+	MarkersInRange(startPos, endPos int) []*Marker
+	// RemoveMarker stops updating the given Marker, returning false if it was not added to this Rope.
+	RemoveMarker(m *Marker) bool
+	// AddAnnotation adds an Annotation over [startPos,endPos), which are clamped to [0,Len()].
+	// It moves and stretches as content is inserted or removed, and is removed once all its content is removed.
+	// Costs O(1), but every change to the Rope then costs O(k) for k markers and annotations.
+	AddAnnotation(startPos, endPos int, payload any) *Annotation
+	// RemoveAnnotation stops updating the given Annotation, returning false if it is not part of this Rope.
+	RemoveAnnotation(a *Annotation) bool
+	// CommonPrefixLen returns how many leading entries this and the other Rope share, with the same Id, Len and Data.
+	// Costs O(k), where k is the result.
+	CommonPrefixLen(other Rope[Id, T]) int
+	// CommonSuffixLen returns how many trailing entries this and the other Rope share, with the same Id, Len and Data.
+	// Costs O(k), where k is the result.
+	CommonSuffixLen(other Rope[Id, T]) int
+	// SameOrder returns whether this and the other Rope have the same Ids in the same order, ignoring Len and Data.
+	// Costs O(1) if the counts differ, otherwise O(n).
+	SameOrder(other Rope[Id, T]) bool
 }
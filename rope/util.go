@@ -5,16 +5,82 @@ import (
 	"math/rand/v2"
 )
 
-// randomHeight picks a height in the range [1,32], inclusive.
+// randomHeight picks a height in the range [1,heightLimit], inclusive.
 // The odds of returning 1 is 50%, 2 is 25%, 3 is 12.5%, and so on.
-func randomHeight() int {
-	// 1 + TrailingZeros is a geometric distribution.
-	// We cap it at maxHeight (32).
-	// rand.Uint32() can be zero, in which case TrailingZeros32 is 32.
-	// So h can be at most 33, which we cap.
-	h := 1 + bits.TrailingZeros32(rand.Uint32())
-	if h > maxHeight {
-		return maxHeight
-	}
-	return h
+// Each height only uses the bits it needs from a buffered 64-bit draw, so one draw gives ~32 heights.
+func (r *ropeImpl[Id, T]) randomHeight() int {
+	h := 1
+	for h < r.heightLimit {
+		if r.heightBitsLeft == 0 {
+			if r.rng != nil {
+				r.heightBits = r.rng.Uint64()
+			} else {
+				r.heightBits = rand.Uint64()
+			}
+			r.heightBitsLeft = 64
+		}
+
+		// Each zero bit before the first one bit adds a level, which is a geometric distribution.
+		// Bits past those left are always zero, so a run reaching them continues into the next draw.
+		tz := bits.TrailingZeros64(r.heightBits)
+		if tz >= r.heightBitsLeft {
+			h += r.heightBitsLeft
+			r.heightBitsLeft = 0
+			continue
+		}
+		h += tz
+		r.heightBits >>= tz + 1
+		r.heightBitsLeft -= tz + 1
+		break
+	}
+	return min(h, r.heightLimit)
+}
+
+// hasLen returns 1 if length is non-zero, for counting nodes with content.
+func hasLen(length int) int {
+	if length != 0 {
+		return 1
+	}
+	return 0
+}
+
+// getNodes returns a buffer of n nodes, using stack if it is large enough, otherwise from a pool.
+// Return the pooled buffer (nil for stack) with putNodes.
+func (r *ropeImpl[Id, T]) getNodes(stack *[maxHeight]*ropeNode[Id, T], n int) ([]*ropeNode[Id, T], *[]*ropeNode[Id, T]) {
+	if n <= maxHeight {
+		return stack[:n], nil
+	}
+	p, _ := r.nodeScratch.Get().(*[]*ropeNode[Id, T])
+	if p == nil || cap(*p) < n {
+		buf := make([]*ropeNode[Id, T], n)
+		p = &buf
+	}
+	return (*p)[:n], p
+}
+
+func (r *ropeImpl[Id, T]) putNodes(p *[]*ropeNode[Id, T]) {
+	if p != nil {
+		clear(*p)
+		r.nodeScratch.Put(p)
+	}
+}
+
+// getSeek is as getNodes, but for seeks.
+func (r *ropeImpl[Id, T]) getSeek(stack *[maxHeight]ropeSeek[Id, T], n int) ([]ropeSeek[Id, T], *[]ropeSeek[Id, T]) {
+	if n <= maxHeight {
+		return stack[:n], nil
+	}
+	p, _ := r.seekScratch.Get().(*[]ropeSeek[Id, T])
+	if p == nil || cap(*p) < n {
+		buf := make([]ropeSeek[Id, T], n)
+		p = &buf
+	}
+	return (*p)[:n], p
+}
+
+func (r *ropeImpl[Id, T]) putSeek(p *[]ropeSeek[Id, T]) {
+	if p != nil {
+		clear(*p)
+		r.seekScratch.Put(p)
+	}
 }
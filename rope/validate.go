@@ -0,0 +1,110 @@
+package rope
+
+import (
+	"fmt"
+)
+
+func (r *ropeImpl[Id, T]) Validate() error {
+	if len(r.head.levels) != r.height {
+		return fmt.Errorf("head has %d levels, height=%d", len(r.head.levels), r.height)
+	}
+
+	// check level zero, which contains every node
+	var count, length, content int
+	var measure measures
+	last := &r.head
+	for node := r.head.levels[0].next; node != nil; node = node.levels[0].next {
+		if node.levels[0].prev != last {
+			return fmt.Errorf("id=%v has bad prev", node.id)
+		} else if r.byId[node.id] != node {
+			return fmt.Errorf("id=%v is not indexed", node.id)
+		} else if len(node.levels) == 0 || len(node.levels) > r.height {
+			return fmt.Errorf("id=%v has bad height=%d", node.id, len(node.levels))
+		} else if node.dl.Len < 0 {
+			return fmt.Errorf("id=%v has negative len=%d", node.id, node.dl.Len)
+		} else if m := r.measureOf(node.dl.Data); r.levelMeasure(node, 0) != m {
+			return fmt.Errorf("id=%v has measure=%v, expected=%v", node.id, r.levelMeasure(node, 0), m)
+		}
+		count++
+		length += node.dl.Len
+		content += hasLen(node.dl.Len)
+		measure = measure.add(r.levelMeasure(node, 0))
+		last = node
+	}
+	if count != r.Count() {
+		return fmt.Errorf("found %d nodes, expected count=%d", count, r.Count())
+	} else if length != r.len {
+		return fmt.Errorf("found len=%d, expected len=%d", length, r.len)
+	} else if content != r.contentCount {
+		return fmt.Errorf("found %d nodes with length, expected contentCount=%d", content, r.contentCount)
+	} else if measure != r.measure {
+		return fmt.Errorf("found measure=%v, expected measure=%v", measure, r.measure)
+	} else if last != r.tail {
+		return fmt.Errorf("last id=%v, expected tail id=%v", last.id, r.tail.id)
+	}
+
+	// check every level by walking level zero alongside it
+	for h := range r.height {
+		curr := &r.head
+		var sub, count int
+		var measure measures
+
+		check := func() error {
+			l := curr.levels[h]
+			if l.subtreesize != sub {
+				return fmt.Errorf("id=%v at level=%d has subtreesize=%d, expected=%d", curr.id, h, l.subtreesize, sub)
+			} else if l.count != count {
+				return fmt.Errorf("id=%v at level=%d has count=%d, expected=%d", curr.id, h, l.count, count)
+			} else if m := r.levelMeasure(curr, h); m != measure {
+				return fmt.Errorf("id=%v at level=%d has measure=%v, expected=%v", curr.id, h, m, measure)
+			} else if l.next != nil && l.next.levels[h].prev != curr {
+				return fmt.Errorf("id=%v at level=%d has bad prev", l.next.id, h)
+			}
+			return nil
+		}
+
+		for node := r.head.levels[0].next; node != nil; node = node.levels[0].next {
+			if len(node.levels) > h {
+				if curr.levels[h].next != node {
+					return fmt.Errorf("id=%v skipped at level=%d", node.id, h)
+				} else if err := check(); err != nil {
+					return err
+				}
+				curr = node
+				sub, count, measure = 0, 0, measures{}
+			}
+			sub += node.dl.Len
+			count++
+			measure = measure.add(r.levelMeasure(node, 0))
+		}
+
+		if curr.levels[h].next != nil {
+			return fmt.Errorf("id=%v at level=%d has next after end", curr.id, h)
+		} else if err := check(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *ropeImpl[Id, T]) Reachable(id Id) bool {
+	if id == r.head.id {
+		return true
+	}
+
+	// bounded, in case the links loop
+	node := &r.head
+	for range r.Count() + 1 {
+		if len(node.levels) == 0 {
+			return false
+		}
+		node = node.levels[0].next
+		if node == nil {
+			return false
+		} else if node.id == id {
+			return true
+		}
+	}
+	return false
+}